@@ -25,6 +25,10 @@ package blackboard
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
 )
 
 var (
@@ -34,13 +38,81 @@ var (
 
 type IBlackBoard interface{}
 
+// number of shards, a power of 2
+const shardCount = 16
+
+/*
+ * BlackBoard is safe for concurrent use: keys are spread over shards, each
+ * guarded by a RWMutex, so that goroutines writing perceptions and the one
+ * updating the tree seldom wait for each other. CompareAndSwap, Update and
+ * AddInt change a value atomically. A blackboard may be the child scope of
+ * another one, see NewChild.
+ */
 type BlackBoard struct {
-	board map[int]interface{}
+	shards [shardCount]shard
+	logger *slog.Logger
+	parent *BlackBoard
+
+	globalMu sync.RWMutex
+	globals  map[int]bool
+	schemaMu sync.RWMutex
+	schema   *Schema
+}
+
+type shard struct {
+	mu     sync.RWMutex
+	values map[int]interface{}
 }
 
 func NewBlackboard() *BlackBoard {
-	mBoard := make(map[int]interface{}, 1000)
-	return &BlackBoard{board: mBoard}
+	b := &BlackBoard{}
+	for i := range b.shards {
+		b.shards[i].values = map[int]interface{}{}
+	}
+	return b
+}
+
+// SetLogger makes the getters log a warning on ErrInvalidType, nil disables it.
+// It must be called before the blackboard is shared by goroutines.
+func (b *BlackBoard) SetLogger(logger *slog.Logger) *BlackBoard {
+	b.logger = logger
+	return b
+}
+
+func (b *BlackBoard) typeError(key int, want string, v interface{}) error {
+	if b.logger != nil {
+		b.logger.Warn("blackboard type error", slog.Int("key", key), slog.String("want", want), slog.String("got", fmt.Sprintf("%T", v)))
+	}
+	return ErrInvalidType
+}
+
+func (b *BlackBoard) shard(key int) *shard {
+	return &b.shards[uint(key)%shardCount]
+}
+
+// value of key in the nearest scope which has it
+func (b *BlackBoard) load(key int) (interface{}, bool) {
+	for scope := b; scope != nil; scope = scope.parent {
+		if v, ok := scope.loadLocal(key); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (b *BlackBoard) loadLocal(key int) (interface{}, bool) {
+	s := b.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (b *BlackBoard) store(key int, v interface{}) {
+	s := b.writeScope(key).shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = v
 }
 
 /*
@@ -51,82 +123,220 @@ func NewBlackboard() *BlackBoard {
  * GetValuesAsInterface, SetValuesAsInterface
  */
 func (b *BlackBoard) GetValueAsBool(key int) (bool, error) {
-	if v, ok := b.board[key]; ok {
+	if v, ok := b.load(key); ok {
 		if i, ok := v.(bool); ok {
 			return i, nil
 		}
-		return false, ErrInvalidType
+		return false, b.typeError(key, "bool", v)
 	}
 	return false, ErrInvalidKey
 }
 
-func (b *BlackBoard) SetValueAsBool(key int, v bool) {
-	b.board[key] = v
+func (b *BlackBoard) SetValueAsBool(key int, v bool) error {
+	return b.Set(key, v)
 }
 
 func (b *BlackBoard) GetValueAsInt(key int) (int, error) {
-	if v, ok := b.board[key]; ok {
+	if v, ok := b.load(key); ok {
 		if i, ok := v.(int); ok {
 			return i, nil
 		}
-		return 0, ErrInvalidType
+		return 0, b.typeError(key, "int", v)
 	}
 	return 0, ErrInvalidKey
 }
 
-func (b *BlackBoard) SetValueAsInt(key int, v int) {
-	b.board[key] = v
+func (b *BlackBoard) SetValueAsInt(key int, v int) error {
+	return b.Set(key, v)
 }
 
 func (b *BlackBoard) GetValueAsFloat32(key int) (float32, error) {
-	if v, ok := b.board[key]; ok {
+	if v, ok := b.load(key); ok {
 		if i, ok := v.(float32); ok {
 			return i, nil
 		}
-		return 0, ErrInvalidType
+		return 0, b.typeError(key, "float32", v)
 	}
 	return 0, ErrInvalidKey
 }
 
-func (b *BlackBoard) SetValueAsFloat32(key int, v float32) {
-	b.board[key] = v
+func (b *BlackBoard) SetValueAsFloat32(key int, v float32) error {
+	return b.Set(key, v)
 }
 
 func (b *BlackBoard) GetValueAsFloat64(key int) (float64, error) {
-	if v, ok := b.board[key]; ok {
+	if v, ok := b.load(key); ok {
 		if i, ok := v.(float64); ok {
 			return i, nil
 		}
-		return 0, ErrInvalidType
+		return 0, b.typeError(key, "float64", v)
 	}
 	return 0, ErrInvalidKey
 }
 
-func (b *BlackBoard) SetValueAsFloat64(key int, v float64) {
-	b.board[key] = v
+func (b *BlackBoard) SetValueAsFloat64(key int, v float64) error {
+	return b.Set(key, v)
 }
 
 func (b *BlackBoard) GetValueAsString(key int) (string, error) {
-	if v, ok := b.board[key]; ok {
+	if v, ok := b.load(key); ok {
 		if i, ok := v.(string); ok {
 			return i, nil
 		}
-		return "", ErrInvalidType
+		return "", b.typeError(key, "string", v)
 	}
 	return "", ErrInvalidKey
 }
 
-func (b *BlackBoard) SetValueAsString(key int, v string) {
-	b.board[key] = v
+func (b *BlackBoard) SetValueAsString(key int, v string) error {
+	return b.Set(key, v)
 }
 
 func (b *BlackBoard) GetValueAsInterface(key int) (interface{}, error) {
-	if v, ok := b.board[key]; ok {
+	if v, ok := b.load(key); ok {
 		return v, nil
 	}
 	return nil, ErrInvalidKey
 }
 
-func (b *BlackBoard) SetValueAsInterface(key int, v interface{}) {
-	b.board[key] = v
+func (b *BlackBoard) SetValueAsInterface(key int, v interface{}) error {
+	return b.Set(key, v)
+}
+
+/*
+ * Range calls f for every key and value until f returns false, the keys of
+ * the parent scopes included unless a nearer scope shadows them. The values of
+ * a shard are copied before f is called, so f may read and write the blackboard.
+ */
+func (b *BlackBoard) Range(f func(key int, value interface{}) bool) {
+	seen := map[int]bool{}
+	for scope := b; scope != nil; scope = scope.parent {
+		if !scope.rangeLocal(func(key int, value interface{}) bool {
+			if seen[key] {
+				return true
+			}
+			seen[key] = true
+			return f(key, value)
+		}) {
+			return
+		}
+	}
+}
+
+// Range on the keys of this scope only, false when f stopped it
+func (b *BlackBoard) rangeLocal(f func(key int, value interface{}) bool) bool {
+	for i := range b.shards {
+		s := &b.shards[i]
+		s.mu.RLock()
+		values := make(map[int]interface{}, len(s.values))
+		for k, v := range s.values {
+			values[k] = v
+		}
+		s.mu.RUnlock()
+
+		for k, v := range values {
+			if !f(k, v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Delete removes key from the scope it is written to, a parent scope may still have it
+func (b *BlackBoard) Delete(key int) {
+	s := b.writeScope(key).shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+/*
+ * CompareAndSwap sets key to new if its value is old and returns whether it
+ * did. A nil old only matches a missing key, values are compared with == and
+ * a value of a type which is not comparable never matches. new is checked by
+ * the schema like the values of Set.
+ */
+func (b *BlackBoard) CompareAndSwap(key int, old interface{}, new interface{}) (bool, error) {
+	swapped := false
+	err := b.Update(key, func(v interface{}, ok bool) (interface{}, bool) {
+		if !ok {
+			swapped = old == nil
+		} else if old != nil && reflect.TypeOf(v) == reflect.TypeOf(old) && reflect.TypeOf(old).Comparable() {
+			swapped = v == old
+		}
+		if swapped {
+			return new, true
+		}
+		return v, ok
+	})
+	if err != nil {
+		return false, err
+	}
+	return swapped, nil
+}
+
+/*
+ * Update replaces the value of key by the result of f, which gets the current
+ * value and whether key is set. key is deleted when f returns false. The shard
+ * of key is locked while f runs, so f must not use the blackboard. The value
+ * is written to the scope of Set, it is only atomic when the key is there and
+ * not read from a parent scope. A value rejected by the schema is not written
+ * and its SchemaError is returned.
+ */
+func (b *BlackBoard) Update(key int, f func(value interface{}, ok bool) (interface{}, bool)) error {
+	schema := b.Schema()
+	scope := b.writeScope(key)
+	s := scope.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	if !ok && scope.parent != nil {
+		// scopes are locked from the child to the parent only
+		v, ok = scope.parent.load(key)
+	}
+	if v, ok = f(v, ok); !ok {
+		delete(s.values, key)
+		return nil
+	}
+	if schema != nil {
+		var err error
+		if v, err = schema.Validate(key, v); err != nil {
+			return b.rejected(key, err)
+		}
+	}
+	s.values[key] = v
+	return nil
+}
+
+// AddInt adds delta to the int of key, a missing key counts as 0, and returns the sum
+func (b *BlackBoard) AddInt(key int, delta int) (int, error) {
+	if schema := b.Schema(); schema != nil {
+		if spec, ok := schema.Spec(key); ok && spec.Type != TypeInt && spec.Type != TypeAny {
+			return 0, b.rejected(key, &SchemaError{key, spec.Name, delta, fmt.Errorf("%w, want %s", ErrInvalidType, spec.Type)})
+		}
+	}
+	var sum int
+	var wrong interface{}
+	isInt := true
+	err := b.Update(key, func(v interface{}, ok bool) (interface{}, bool) {
+		if !ok {
+			sum = delta
+			return sum, true
+		}
+		var i int
+		if i, isInt = v.(int); !isInt {
+			wrong = v
+			return v, true
+		}
+		sum = i + delta
+		return sum, true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !isInt {
+		return 0, b.typeError(key, "int", wrong)
+	}
+	return sum, nil
 }
@@ -24,8 +24,17 @@
 package blackboard
 
 import (
-	. "github.com/smartystreets/goconvey/convey"
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
 	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestBoolValue(t *testing.T) {
@@ -117,3 +126,418 @@ func TestInterfaceValue(t *testing.T) {
 		So(err, ShouldEqual, nil)
 	})
 }
+
+func TestTypeErrorLog(t *testing.T) {
+	Convey("Type errors are logged when a logger is set", t, func() {
+		var buf bytes.Buffer
+		blackboard := NewBlackboard().SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+		blackboard.SetValueAsString(1, "one")
+		_, err := blackboard.GetValueAsInt(1)
+		So(err, ShouldEqual, ErrInvalidType)
+		_, err = blackboard.GetValueAsInt(2)
+		So(err, ShouldEqual, ErrInvalidKey)
+		So(buf.String(), ShouldContainSubstring, `msg="blackboard type error" key=1 want=int got=string`)
+		So(strings.Count(buf.String(), "\n"), ShouldEqual, 1)
+	})
+}
+
+func TestAtomicHelpers(t *testing.T) {
+	Convey("CompareAndSwap only swaps the expected value", t, func() {
+		blackboard := NewBlackboard()
+		cas := func(key int, old interface{}, new interface{}) bool {
+			swapped, err := blackboard.CompareAndSwap(key, old, new)
+			So(err, ShouldBeNil)
+			return swapped
+		}
+		So(cas(1, 1, 2), ShouldBeFalse)
+		So(cas(1, nil, 1), ShouldBeTrue)
+		So(cas(1, 2, 3), ShouldBeFalse)
+		So(cas(1, 1.0, 3), ShouldBeFalse)
+		So(cas(1, 1, 3), ShouldBeTrue)
+		value, _ := blackboard.GetValueAsInt(1)
+		So(value, ShouldEqual, 3)
+
+		blackboard.SetValueAsInterface(2, []int{1})
+		So(cas(2, []int{1}, 3), ShouldBeFalse)
+	})
+
+	Convey("Update and AddInt change values atomically", t, func() {
+		blackboard := NewBlackboard()
+		sum, err := blackboard.AddInt(1, 2)
+		So(sum, ShouldEqual, 2)
+		So(err, ShouldBeNil)
+		sum, _ = blackboard.AddInt(1, 3)
+		So(sum, ShouldEqual, 5)
+		blackboard.SetValueAsString(2, "two")
+		_, err = blackboard.AddInt(2, 1)
+		So(err, ShouldEqual, ErrInvalidType)
+
+		blackboard.Update(1, func(v interface{}, ok bool) (interface{}, bool) {
+			return nil, false
+		})
+		_, err = blackboard.GetValueAsInt(1)
+		So(err, ShouldEqual, ErrInvalidKey)
+		blackboard.Delete(2)
+		_, err = blackboard.GetValueAsInterface(2)
+		So(err, ShouldEqual, ErrInvalidKey)
+	})
+
+	Convey("Goroutines share a blackboard, run with -race", t, func() {
+		blackboard := NewBlackboard()
+		const writers, adds = 8, 1000
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(2)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < adds; i++ {
+					blackboard.AddInt(0, 1)
+					blackboard.SetValueAsInt(w+1, i)
+				}
+			}(w)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < adds; i++ {
+					blackboard.GetValueAsInt(0)
+					blackboard.Range(func(key int, value interface{}) bool {
+						return true
+					})
+				}
+			}()
+		}
+		wg.Wait()
+
+		total, _ := blackboard.GetValueAsInt(0)
+		So(total, ShouldEqual, writers*adds)
+		count := 0
+		blackboard.Range(func(key int, value interface{}) bool {
+			count++
+			return true
+		})
+		So(count, ShouldEqual, writers+1)
+	})
+}
+
+/*
+ * Benchmarks of the blackboard against a map, plain for one goroutine and
+ * guarded by a RWMutex when shared, with 9 reads for 1 write
+ */
+const benchKeys = 64
+
+type lockedMap struct {
+	mu     sync.RWMutex
+	values map[int]interface{}
+}
+
+func BenchmarkMap(b *testing.B) {
+	values := map[int]interface{}{}
+	for i := 0; i < b.N; i++ {
+		if i%10 == 0 {
+			values[i%benchKeys] = i
+		} else {
+			_ = values[i%benchKeys]
+		}
+	}
+}
+
+func BenchmarkBlackboard(b *testing.B) {
+	blackboard := NewBlackboard()
+	for i := 0; i < b.N; i++ {
+		if i%10 == 0 {
+			blackboard.SetValueAsInt(i%benchKeys, i)
+		} else {
+			blackboard.GetValueAsInt(i % benchKeys)
+		}
+	}
+}
+
+func BenchmarkLockedMapParallel(b *testing.B) {
+	m := &lockedMap{values: map[int]interface{}{}}
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if i%10 == 0 {
+				m.mu.Lock()
+				m.values[i%benchKeys] = i
+				m.mu.Unlock()
+			} else {
+				m.mu.RLock()
+				_ = m.values[i%benchKeys]
+				m.mu.RUnlock()
+			}
+		}
+	})
+}
+
+func BenchmarkBlackboardParallel(b *testing.B) {
+	blackboard := NewBlackboard()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if i%10 == 0 {
+				blackboard.SetValueAsInt(i%benchKeys, i)
+			} else {
+				blackboard.GetValueAsInt(i % benchKeys)
+			}
+		}
+	})
+}
+
+func BenchmarkBlackboardAddIntParallel(b *testing.B) {
+	blackboard := NewBlackboard()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			blackboard.AddInt(i%benchKeys, 1)
+		}
+	})
+}
+
+func TestScopes(t *testing.T) {
+	const keyTime, keyHp, keyPath = 1, 2, 3
+
+	Convey("Children read their parents and write locally", t, func() {
+		world := NewBlackboard().MarkGlobal(keyTime)
+		agent := world.NewChild()
+		scratch := agent.NewChild()
+		So(scratch.Parent(), ShouldEqual, agent)
+		So(world.Parent(), ShouldBeNil)
+
+		world.SetValueAsInt(keyTime, 1)
+		agent.SetValueAsInt(keyHp, 100)
+		hp, err := scratch.GetValueAsInt(keyHp)
+		So(hp, ShouldEqual, 100)
+		So(err, ShouldBeNil)
+		So(scratch.ScopeOf(keyHp), ShouldEqual, agent)
+
+		scratch.SetValueAsInt(keyHp, 50)
+		scratch.SetValueAsInt(keyPath, 3)
+		hp, _ = agent.GetValueAsInt(keyHp)
+		So(hp, ShouldEqual, 100)
+		hp, _ = scratch.GetValueAsInt(keyHp)
+		So(hp, ShouldEqual, 50)
+		_, err = agent.GetValueAsInt(keyPath)
+		So(err, ShouldEqual, ErrInvalidKey)
+		So(scratch.HasLocal(keyPath), ShouldBeTrue)
+
+		scratch.SetValueAsInt(keyTime, 2)
+		So(scratch.HasLocal(keyTime), ShouldBeFalse)
+		time, _ := world.GetValueAsInt(keyTime)
+		So(time, ShouldEqual, 2)
+		sum, _ := scratch.AddInt(keyTime, 1)
+		So(sum, ShouldEqual, 3)
+		time, _ = world.GetValueAsInt(keyTime)
+		So(time, ShouldEqual, 3)
+
+		_, err = scratch.GetValueAsInt(9)
+		So(err, ShouldEqual, ErrInvalidKey)
+		So(scratch.ScopeOf(9), ShouldBeNil)
+	})
+
+	Convey("Range shows every visible key once and deleting unshadows", t, func() {
+		agent := NewBlackboard()
+		agent.SetValueAsInt(keyHp, 100)
+		agent.SetValueAsInt(keyTime, 1)
+		scratch := agent.NewChild()
+		scratch.SetValueAsInt(keyHp, 50)
+
+		values := map[int]interface{}{}
+		scratch.Range(func(key int, value interface{}) bool {
+			values[key] = value
+			return true
+		})
+		So(values, ShouldResemble, map[int]interface{}{keyHp: 50, keyTime: 1})
+
+		scratch.Delete(keyHp)
+		hp, _ := scratch.GetValueAsInt(keyHp)
+		So(hp, ShouldEqual, 100)
+
+		swapped, err := scratch.CompareAndSwap(keyTime, 1, 2)
+		So(swapped, ShouldBeTrue)
+		So(err, ShouldBeNil)
+		time, _ := agent.GetValueAsInt(keyTime)
+		So(time, ShouldEqual, 1)
+		time, _ = scratch.GetValueAsInt(keyTime)
+		So(time, ShouldEqual, 2)
+	})
+}
+
+func TestSchema(t *testing.T) {
+	const keyHp, keyMood, keySpeed = 1, 2, 3
+	newSchema := func() *Schema {
+		return NewSchema().
+			MustDeclare(KeySpec{Key: keyHp, Name: "hp", Type: TypeInt, Default: 100, Owner: "combat", Description: "Hit points"}).
+			MustDeclare(KeySpec{Key: keyMood, Name: "mood", Type: TypeString, Default: "idle", Owner: "ai", Description: "Current | mood"}).
+			MustDeclare(KeySpec{Key: keySpeed, Name: "speed", Type: TypeFloat64, Description: "Meters per second"})
+	}
+
+	Convey("Declarations are checked", t, func() {
+		schema := newSchema()
+		So(schema.Declare(KeySpec{Key: keyHp, Name: "life"}), ShouldNotBeNil)
+		So(schema.Declare(KeySpec{Key: 9, Name: "hp"}), ShouldNotBeNil)
+		So(schema.Declare(KeySpec{Key: 9, Type: TypeInt, Default: "ten"}), ShouldNotBeNil)
+		spec, ok := schema.Lookup("speed")
+		So(ok, ShouldBeTrue)
+		So(spec.Key, ShouldEqual, keySpeed)
+		So(schema.Specs(), ShouldHaveLength, 3)
+		kind, err := ParseKeyType("float32")
+		So(kind, ShouldEqual, TypeFloat32)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Defaults are materialized and writes validated", t, func() {
+		var buf bytes.Buffer
+		board := NewBlackboard().SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+		board.SetValueAsInt(keyHp, 50)
+		board.SetSchema(newSchema())
+		hp, _ := board.GetValueAsInt(keyHp)
+		So(hp, ShouldEqual, 50)
+		mood, _ := board.GetValueAsString(keyMood)
+		So(mood, ShouldEqual, "idle")
+		So(board.HasLocal(keySpeed), ShouldBeFalse)
+
+		board.SetValueAsFloat32(keySpeed, 1.5)
+		speed, err := board.GetValueAsFloat64(keySpeed)
+		So(speed, ShouldEqual, 1.5)
+		So(err, ShouldBeNil)
+
+		So(board.SetValueAsString(keyHp, "dead"), ShouldNotBeNil)
+		hp, _ = board.GetValueAsInt(keyHp)
+		So(hp, ShouldEqual, 50)
+		So(buf.String(), ShouldContainSubstring, `msg="blackboard write rejected" key=1`)
+
+		err = board.SetByName("hp", 1.5)
+		var schemaErr *SchemaError
+		So(errors.As(err, &schemaErr), ShouldBeTrue)
+		So(errors.Is(err, ErrInvalidType), ShouldBeTrue)
+		So(err.Error(), ShouldEqual, "key hp: Invalid Type, want int, got float64")
+		So(board.SetByName("hp", 2.0), ShouldBeNil)
+		hp, _ = board.GetValueAsInt(keyHp)
+		So(hp, ShouldEqual, 2)
+		So(errors.Is(board.SetByName("armor", 1), ErrUndeclaredKey), ShouldBeTrue)
+
+		swapped, err := board.CompareAndSwap(keyHp, 2, "two")
+		So(swapped, ShouldBeFalse)
+		So(errors.Is(err, ErrInvalidType), ShouldBeTrue)
+		swapped, err = board.CompareAndSwap(keySpeed, 1.5, float32(2.5))
+		So(swapped, ShouldBeTrue)
+		speed, err = board.GetValueAsFloat64(keySpeed)
+		So(speed, ShouldEqual, 2.5)
+		So(board.Update(keyMood, func(v interface{}, ok bool) (interface{}, bool) { return 1, true }), ShouldNotBeNil)
+		mood, _ = board.GetValueAsString(keyMood)
+		So(mood, ShouldEqual, "idle")
+		_, err = board.AddInt(keySpeed, 1)
+		So(errors.Is(err, ErrInvalidType), ShouldBeTrue)
+		speed, _ = board.GetValueAsFloat64(keySpeed)
+		So(speed, ShouldEqual, 2.5)
+		sum, err := board.AddInt(keyHp, 1)
+		So(sum, ShouldEqual, 3)
+		So(err, ShouldBeNil)
+
+		So(board.Set(9, true), ShouldBeNil)
+		board.Schema().SetStrict(true)
+		So(errors.Is(board.Set(9, true), ErrUndeclaredKey), ShouldBeTrue)
+
+		child := board.NewChild()
+		So(child.Schema(), ShouldEqual, board.Schema())
+		So(child.SetByName("mood", 3), ShouldNotBeNil)
+		v, _ := child.GetByName("mood")
+		So(v, ShouldEqual, "idle")
+	})
+
+	Convey("The doc lists every key", t, func() {
+		var buf bytes.Buffer
+		So(newSchema().WriteDoc(&buf), ShouldBeNil)
+		So(buf.String(), ShouldEqual, ""+
+			"| Key | Name | Type | Default | Owner | Description |\n"+
+			"|-----|------|------|---------|-------|-------------|\n"+
+			"| 1 | hp | int | 100 | combat | Hit points |\n"+
+			"| 2 | mood | string | \"idle\" | ai | Current \\| mood |\n"+
+			"| 3 | speed | float64 |  |  | Meters per second |\n")
+	})
+}
+
+type position struct {
+	X, Y float32
+}
+
+func TestPersistence(t *testing.T) {
+	RegisterType("blackboard.position", position{})
+	newBoard := func() *BlackBoard {
+		board := NewBlackboard().MarkGlobal(1)
+		board.SetValueAsInt(1, 3)
+		board.SetValueAsFloat32(2, 1.5)
+		board.SetValueAsFloat64(3, 2)
+		board.SetValueAsString(4, "idle")
+		board.SetValueAsBool(5, true)
+		board.SetValueAsInterface(6, position{1, 2})
+		board.SetValueAsInterface(7, nil)
+		return board
+	}
+	check := func(board *BlackBoard) {
+		i, _ := board.GetValueAsInt(1)
+		So(i, ShouldEqual, 3)
+		f32, err := board.GetValueAsFloat32(2)
+		So(f32, ShouldEqual, 1.5)
+		So(err, ShouldBeNil)
+		f64, err := board.GetValueAsFloat64(3)
+		So(f64, ShouldEqual, 2)
+		So(err, ShouldBeNil)
+		s, _ := board.GetValueAsString(4)
+		So(s, ShouldEqual, "idle")
+		v, _ := board.GetValueAsBool(5)
+		So(v, ShouldBeTrue)
+		pos, _ := board.GetValueAsInterface(6)
+		So(pos, ShouldResemble, position{1, 2})
+		So(board.HasLocal(7), ShouldBeTrue)
+		So(board.NewChild().writeScope(1), ShouldEqual, board)
+	}
+
+	Convey("Values keep their type through json", t, func() {
+		data, err := json.Marshal(newBoard())
+		So(err, ShouldBeNil)
+		So(string(data), ShouldStartWith, `{"values":[{"key":1,"type":"int","value":3},{"key":2,"type":"float32","value":1.5},`)
+
+		board := NewBlackboard()
+		board.SetValueAsInt(9, 1)
+		So(json.Unmarshal(data, board), ShouldBeNil)
+		So(board.HasLocal(9), ShouldBeFalse)
+		check(board)
+	})
+
+	Convey("Values keep their type through gob", t, func() {
+		var buf bytes.Buffer
+		So(gob.NewEncoder(&buf).Encode(newBoard()), ShouldBeNil)
+		var board BlackBoard
+		So(gob.NewDecoder(&buf).Decode(&board), ShouldBeNil)
+		check(&board)
+	})
+
+	Convey("Unserializable values are reported", t, func() {
+		board := newBoard()
+		board.SetValueAsInterface(8, make(chan int))
+		board.SetValueAsFloat64(3, math.NaN())
+		_, err := json.Marshal(board)
+		var perr *PersistError
+		So(errors.As(err, &perr), ShouldBeTrue)
+		So(perr.Entries, ShouldHaveLength, 2)
+		So(perr.Entries[0].Key, ShouldEqual, 3)
+		So(errors.Is(err, ErrUnregisteredType), ShouldBeTrue)
+		So(perr.Entries[1].Error(), ShouldEqual, "key 8 (chan int): Unregistered Type")
+
+		_, err = board.GobEncode()
+		So(errors.As(err, &perr), ShouldBeTrue)
+		So(perr.Entries, ShouldHaveLength, 1)
+	})
+
+	Convey("Restored values which fail are reported after the others", t, func() {
+		board := NewBlackboard().SetSchema(NewSchema().MustDeclare(KeySpec{Key: 2, Type: TypeString}))
+		err := board.UnmarshalJSON([]byte(`{"values":[{"key":1,"type":"int","value":3},` +
+			`{"key":2,"type":"int","value":4},{"key":3,"type":"game.Unit","value":{}}]}`))
+		var perr *PersistError
+		So(errors.As(err, &perr), ShouldBeTrue)
+		So(perr.Op, ShouldEqual, "decode")
+		So(perr.Entries, ShouldHaveLength, 2)
+		So(errors.Is(err, ErrInvalidType), ShouldBeTrue)
+		So(errors.Is(err, ErrUnregisteredType), ShouldBeTrue)
+		i, _ := board.GetValueAsInt(1)
+		So(i, ShouldEqual, 3)
+	})
+}
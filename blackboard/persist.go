@@ -0,0 +1,339 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package blackboard
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnregisteredType = errors.New("Unregistered Type")
+)
+
+/*
+ * registry of the types a blackboard can save, by name. The values are saved
+ * with the name of their type so that a float32 is restored as a float32 and
+ * not as the float64 of a json number.
+ */
+var registry = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}{types: map[string]reflect.Type{}, names: map[reflect.Type]string{}}
+
+func init() {
+	RegisterType("bool", false)
+	RegisterType("int", 0)
+	RegisterType("float32", float32(0))
+	RegisterType("float64", float64(0))
+	RegisterType("string", "")
+}
+
+/*
+ * RegisterType makes the values of the type of value, which are set with
+ * SetValueAsInterface, savable under name. Like gob.RegisterName it panics
+ * when the name or the type is already registered to another type or name.
+ *
+ *	blackboard.RegisterType("game.Vec2", Vec2{})
+ *	blackboard.RegisterType("*game.Unit", (*Unit)(nil))
+ */
+func RegisterType(name string, value interface{}) {
+	if name == "" || name == "nil" || value == nil {
+		panic("blackboard: RegisterType needs a name and a non nil value")
+	}
+	t := reflect.TypeOf(value)
+	registry.Lock()
+	defer registry.Unlock()
+	if other, ok := registry.types[name]; ok && other != t {
+		panic(fmt.Sprintf("blackboard: name %q is registered to %v", name, other))
+	}
+	if other, ok := registry.names[t]; ok && other != name {
+		panic(fmt.Sprintf("blackboard: type %v is registered as %q", t, other))
+	}
+	registry.types[name] = t
+	registry.names[t] = name
+}
+
+func typeName(v interface{}) (string, error) {
+	if v == nil {
+		return "nil", nil
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	if name, ok := registry.names[reflect.TypeOf(v)]; ok {
+		return name, nil
+	}
+	return fmt.Sprintf("%T", v), ErrUnregisteredType
+}
+
+func registeredType(name string) (reflect.Type, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	if t, ok := registry.types[name]; ok {
+		return t, nil
+	}
+	return nil, ErrUnregisteredType
+}
+
+// EntryError is a value which could not be saved or restored
+type EntryError struct {
+	Key  int
+	Type string
+	Err  error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("key %d (%s): %v", e.Key, e.Type, e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// PersistError lists the entries which failed to be saved, Op "encode", or restored, Op "decode"
+type PersistError struct {
+	Op      string
+	Entries []*EntryError
+}
+
+func (e *PersistError) Error() string {
+	msgs := make([]string, len(e.Entries))
+	for i, entry := range e.Entries {
+		msgs[i] = entry.Error()
+	}
+	return fmt.Sprintf("blackboard %s: %s", e.Op, strings.Join(msgs, "; "))
+}
+
+func (e *PersistError) Unwrap() []error {
+	errs := make([]error, len(e.Entries))
+	for i, entry := range e.Entries {
+		errs[i] = entry
+	}
+	return errs
+}
+
+type jsonEntry struct {
+	Key   int             `json:"key"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type jsonBoard struct {
+	Values  []jsonEntry `json:"values"`
+	Globals []int       `json:"globals,omitempty"`
+}
+
+type gobEntry struct {
+	Key   int
+	Type  string
+	Value []byte
+}
+
+type gobBoard struct {
+	Values  []gobEntry
+	Globals []int
+}
+
+type codec struct {
+	encode func(v interface{}) ([]byte, error)
+	decode func(data []byte, v interface{}) error
+}
+
+var jsonCodec = codec{json.Marshal, json.Unmarshal}
+
+var gobCodec = codec{
+	func(v interface{}) ([]byte, error) {
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(v)
+		return buf.Bytes(), err
+	},
+	func(data []byte, v interface{}) error {
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+	},
+}
+
+type entry struct {
+	key   int
+	name  string
+	value []byte
+}
+
+/*
+ * encode the values of b itself, sorted by key. Values of the parent scopes
+ * are not saved, they are saved with the parents.
+ */
+func (b *BlackBoard) encode(c codec) ([]entry, []int, error) {
+	var keys []int
+	values := map[int]interface{}{}
+	b.rangeLocal(func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		values[key] = value
+		return true
+	})
+	sort.Ints(keys)
+
+	entries := make([]entry, 0, len(keys))
+	perr := &PersistError{Op: "encode"}
+	for _, key := range keys {
+		name, err := typeName(values[key])
+		var data []byte
+		if err == nil && values[key] != nil {
+			data, err = c.encode(values[key])
+		}
+		if err != nil {
+			perr.Entries = append(perr.Entries, &EntryError{key, name, err})
+			continue
+		}
+		entries = append(entries, entry{key, name, data})
+	}
+	if len(perr.Entries) > 0 {
+		return nil, nil, perr
+	}
+
+	b.globalMu.RLock()
+	globals := make([]int, 0, len(b.globals))
+	for key := range b.globals {
+		globals = append(globals, key)
+	}
+	b.globalMu.RUnlock()
+	sort.Ints(globals)
+	return entries, globals, nil
+}
+
+/*
+ * replace the values of b with entries. The entries which fail are reported
+ * once the others are restored, like the values rejected by the schema of b.
+ */
+func (b *BlackBoard) decode(c codec, entries []entry, globals []int) error {
+	for i := range b.shards {
+		s := &b.shards[i]
+		s.mu.Lock()
+		s.values = map[int]interface{}{}
+		s.mu.Unlock()
+	}
+	b.globalMu.Lock()
+	b.globals = nil
+	b.globalMu.Unlock()
+	b.MarkGlobal(globals...)
+
+	schema := b.Schema()
+	perr := &PersistError{Op: "decode"}
+	for _, e := range entries {
+		var value interface{}
+		if e.name != "nil" {
+			t, err := registeredType(e.name)
+			if err == nil {
+				ptr := reflect.New(t)
+				err = c.decode(e.value, ptr.Interface())
+				value = ptr.Elem().Interface()
+			}
+			if err == nil && schema != nil {
+				value, err = schema.Validate(e.key, value)
+			}
+			if err != nil {
+				perr.Entries = append(perr.Entries, &EntryError{e.key, e.name, err})
+				continue
+			}
+		}
+		s := b.shard(e.key)
+		s.mu.Lock()
+		s.values[e.key] = value
+		s.mu.Unlock()
+	}
+	if len(perr.Entries) > 0 {
+		return perr
+	}
+	return nil
+}
+
+/*
+ * MarshalJSON saves the values of b with their type, like
+ *
+ *	{"values":[{"key":1,"type":"int","value":100},{"key":2,"type":"float32","value":1.5}],"globals":[1]}
+ *
+ * It fails with a PersistError listing the values which can not be saved, of
+ * types not registered with RegisterType or rejected by json. The values are
+ * read key by key, a consistent snapshot needs the writers to be stopped.
+ */
+func (b *BlackBoard) MarshalJSON() ([]byte, error) {
+	entries, globals, err := b.encode(jsonCodec)
+	if err != nil {
+		return nil, err
+	}
+	board := jsonBoard{Values: make([]jsonEntry, len(entries)), Globals: globals}
+	for i, e := range entries {
+		board.Values[i] = jsonEntry{e.key, e.name, e.value}
+		if e.value == nil {
+			board.Values[i].Value = json.RawMessage("null")
+		}
+	}
+	return json.Marshal(board)
+}
+
+// UnmarshalJSON replaces the values of b with the ones saved by MarshalJSON
+func (b *BlackBoard) UnmarshalJSON(data []byte) error {
+	var board jsonBoard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return err
+	}
+	entries := make([]entry, len(board.Values))
+	for i, e := range board.Values {
+		entries[i] = entry{e.Key, e.Type, e.Value}
+	}
+	return b.decode(jsonCodec, entries, board.Globals)
+}
+
+// GobEncode saves b like MarshalJSON does
+func (b *BlackBoard) GobEncode() ([]byte, error) {
+	entries, globals, err := b.encode(gobCodec)
+	if err != nil {
+		return nil, err
+	}
+	board := gobBoard{Values: make([]gobEntry, len(entries)), Globals: globals}
+	for i, e := range entries {
+		board.Values[i] = gobEntry{e.key, e.name, e.value}
+	}
+	return gobCodec.encode(board)
+}
+
+// GobDecode replaces the values of b with the ones saved by GobEncode
+func (b *BlackBoard) GobDecode(data []byte) error {
+	var board gobBoard
+	if err := gobCodec.decode(data, &board); err != nil {
+		return err
+	}
+	entries := make([]entry, len(board.Values))
+	for i, e := range board.Values {
+		entries[i] = entry{e.Key, e.Type, e.Value}
+	}
+	return b.decode(gobCodec, entries, board.Globals)
+}
@@ -0,0 +1,366 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package blackboard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUndeclaredKey = errors.New("Undeclared Key")
+)
+
+/*
+ * KeyType of a declared key
+ */
+const (
+	TypeAny KeyType = iota
+	TypeBool
+	TypeInt
+	TypeFloat32
+	TypeFloat64
+	TypeString
+)
+
+type KeyType int
+
+var keyTypeNames = [...]string{"any", "bool", "int", "float32", "float64", "string"}
+
+func (t KeyType) String() string {
+	if t >= 0 && int(t) < len(keyTypeNames) {
+		return keyTypeNames[t]
+	}
+	return fmt.Sprintf("type(%d)", int(t))
+}
+
+// ParseKeyType returns the KeyType named like its String
+func ParseKeyType(s string) (KeyType, error) {
+	for i, name := range keyTypeNames {
+		if name == s {
+			return KeyType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key type %q", s)
+}
+
+/*
+ * convert v to t, numbers are converted to the float types and integral
+ * floats to int, ok is false when v can not be stored as t
+ */
+func (t KeyType) convert(v interface{}) (interface{}, bool) {
+	switch t {
+	case TypeAny:
+		return v, true
+	case TypeBool:
+		b, ok := v.(bool)
+		return b, ok
+	case TypeString:
+		s, ok := v.(string)
+		return s, ok
+	}
+
+	var f float64
+	switch n := v.(type) {
+	case int:
+		f = float64(n)
+	case float32:
+		f = float64(n)
+	case float64:
+		f = n
+	default:
+		return nil, false
+	}
+	switch t {
+	case TypeInt:
+		if i, ok := v.(int); ok {
+			return i, true
+		}
+		if f != float64(int(f)) {
+			return nil, false
+		}
+		return int(f), true
+	case TypeFloat32:
+		return float32(f), true
+	case TypeFloat64:
+		return f, true
+	}
+	return nil, false
+}
+
+// KeySpec declares a blackboard key, Default is stored when the schema is set
+// on a blackboard unless it is nil, Owner tells who writes the key
+type KeySpec struct {
+	Key         int
+	Name        string
+	Type        KeyType
+	Default     interface{}
+	Description string
+	Owner       string
+}
+
+// SchemaError is a write rejected by a Schema
+type SchemaError struct {
+	Key   int
+	Name  string
+	Value interface{}
+	Err   error
+}
+
+func (e *SchemaError) Error() string {
+	name := e.Name
+	if name == "" {
+		name = fmt.Sprintf("%d", e.Key)
+	}
+	return fmt.Sprintf("key %s: %v, got %T", name, e.Err, e.Value)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ * Schema declares the keys of blackboards: their names, types, defaults and
+ * documentation. A blackboard given a schema with SetSchema converts the
+ * values written to the declared type, so a float32 written to a float64 key
+ * reads back with GetValueAsFloat64, and rejects the values it can not convert.
+ */
+type Schema struct {
+	mu     sync.RWMutex
+	specs  map[int]KeySpec
+	names  map[string]int
+	strict bool
+}
+
+func NewSchema() *Schema {
+	return &Schema{specs: map[int]KeySpec{}, names: map[string]int{}}
+}
+
+// Declare adds spec, its key and name must not be declared yet and its default must have its type
+func (s *Schema) Declare(spec KeySpec) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.specs[spec.Key]; ok {
+		return fmt.Errorf("key %d is already declared", spec.Key)
+	}
+	if spec.Name != "" {
+		if key, ok := s.names[spec.Name]; ok {
+			return fmt.Errorf("name %q is already declared by key %d", spec.Name, key)
+		}
+	}
+	if spec.Default != nil {
+		v, ok := spec.Type.convert(spec.Default)
+		if !ok {
+			return fmt.Errorf("key %d: default %v is not a %s", spec.Key, spec.Default, spec.Type)
+		}
+		spec.Default = v
+	}
+
+	s.specs[spec.Key] = spec
+	if spec.Name != "" {
+		s.names[spec.Name] = spec.Key
+	}
+	return nil
+}
+
+// MustDeclare is Declare panicking on error, for schemas built by code
+func (s *Schema) MustDeclare(spec KeySpec) *Schema {
+	if err := s.Declare(spec); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// SetStrict makes blackboards reject writes to undeclared keys
+func (s *Schema) SetStrict(strict bool) *Schema {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.strict = strict
+	return s
+}
+
+func (s *Schema) Spec(key int) (KeySpec, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	spec, ok := s.specs[key]
+	return spec, ok
+}
+
+// Lookup returns the spec declared with name
+func (s *Schema) Lookup(name string) (KeySpec, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.names[name]
+	if !ok {
+		return KeySpec{}, false
+	}
+	return s.specs[key], true
+}
+
+// Specs returns the declared keys sorted by key
+func (s *Schema) Specs() []KeySpec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	specs := make([]KeySpec, 0, len(s.specs))
+	for _, spec := range s.specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Key < specs[j].Key })
+	return specs
+}
+
+// Validate returns v converted to the type declared for key
+func (s *Schema) Validate(key int, v interface{}) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	spec, ok := s.specs[key]
+	if !ok {
+		if s.strict {
+			return nil, &SchemaError{key, "", v, ErrUndeclaredKey}
+		}
+		return v, nil
+	}
+	converted, ok := spec.Type.convert(v)
+	if !ok {
+		return nil, &SchemaError{key, spec.Name, v, fmt.Errorf("%w, want %s", ErrInvalidType, spec.Type)}
+	}
+	return converted, nil
+}
+
+/*
+ * WriteDoc writes a markdown table of the declared keys for designers, like
+ *
+ *	| Key | Name | Type | Default | Owner | Description |
+ *	|-----|------|------|---------|-------|-------------|
+ *	| 1 | hp | int | 100 | perception | Hit points of the agent |
+ */
+func (s *Schema) WriteDoc(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Key | Name | Type | Default | Owner | Description |\n")
+	b.WriteString("|-----|------|------|---------|-------|-------------|\n")
+	for _, spec := range s.Specs() {
+		def := ""
+		if spec.Default != nil {
+			def = fmt.Sprintf("%v", spec.Default)
+			if str, ok := spec.Default.(string); ok {
+				def = fmt.Sprintf("%q", str)
+			}
+		}
+		fmt.Fprintf(&b, "| %d | %s | %s | %s | %s | %s |\n", spec.Key, docCell(spec.Name),
+			spec.Type, docCell(def), docCell(spec.Owner), docCell(spec.Description))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escape the characters breaking a table cell
+func docCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+/*
+ * SetSchema validates the writes to b and to its children against schema and
+ * stores the declared defaults in b. nil removes the schema.
+ */
+func (b *BlackBoard) SetSchema(schema *Schema) *BlackBoard {
+	b.schemaMu.Lock()
+	b.schema = schema
+	b.schemaMu.Unlock()
+	if schema != nil {
+		for _, spec := range schema.Specs() {
+			if spec.Default != nil && !b.HasLocal(spec.Key) {
+				b.store(spec.Key, spec.Default)
+			}
+		}
+	}
+	return b
+}
+
+// Schema returns the schema of b or of its nearest parent scope having one
+func (b *BlackBoard) Schema() *Schema {
+	for scope := b; scope != nil; scope = scope.parent {
+		scope.schemaMu.RLock()
+		schema := scope.schema
+		scope.schemaMu.RUnlock()
+		if schema != nil {
+			return schema
+		}
+	}
+	return nil
+}
+
+/*
+ * Set validates v against the schema before storing it and returns the
+ * SchemaError of a rejected write, like the SetValueAs setters. Rejected writes
+ * are also logged when a logger is set.
+ */
+func (b *BlackBoard) Set(key int, v interface{}) error {
+	if schema := b.Schema(); schema != nil {
+		var err error
+		if v, err = schema.Validate(key, v); err != nil {
+			return b.rejected(key, err)
+		}
+	}
+	b.store(key, v)
+	return nil
+}
+
+// SetByName sets the key declared with name by the schema
+func (b *BlackBoard) SetByName(name string, v interface{}) error {
+	key, err := b.keyOf(name)
+	if err != nil {
+		return err
+	}
+	return b.Set(key, v)
+}
+
+// GetByName returns the value of the key declared with name by the schema
+func (b *BlackBoard) GetByName(name string) (interface{}, error) {
+	key, err := b.keyOf(name)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetValueAsInterface(key)
+}
+
+func (b *BlackBoard) keyOf(name string) (int, error) {
+	if schema := b.Schema(); schema != nil {
+		if spec, ok := schema.Lookup(name); ok {
+			return spec.Key, nil
+		}
+	}
+	return 0, fmt.Errorf("%w %q", ErrUndeclaredKey, name)
+}
+
+func (b *BlackBoard) rejected(key int, err error) error {
+	if b.logger != nil {
+		b.logger.Warn("blackboard write rejected", slog.Int("key", key), slog.String("error", err.Error()))
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package blackboard
+
+/*
+ * NewChild returns a scope of b: getters fall back to b, and its parents, for
+ * the keys the child does not have, setters write to the child unless the key
+ * is marked global by b or one of its parents. A subtree given a child scope
+ * has private scratch space and still reads the agent and world data:
+ *
+ *	world := blackboard.NewBlackboard().MarkGlobal(keyTime)
+ *	agent := world.NewChild()
+ *	scratch := agent.NewChild()
+ *	scratch.SetValueAsInt(keyTime, 10) // written to world
+ *	scratch.SetValueAsInt(keyPath, 3)  // private to scratch
+ */
+func (b *BlackBoard) NewChild() *BlackBoard {
+	child := NewBlackboard()
+	child.parent = b
+	child.logger = b.logger
+	return child
+}
+
+// Parent returns the scope b falls back to, nil for a root scope
+func (b *BlackBoard) Parent() *BlackBoard {
+	return b.parent
+}
+
+// MarkGlobal makes the children of b, and their children, write keys to b
+func (b *BlackBoard) MarkGlobal(keys ...int) *BlackBoard {
+	b.globalMu.Lock()
+	defer b.globalMu.Unlock()
+	if b.globals == nil {
+		b.globals = map[int]bool{}
+	}
+	for _, key := range keys {
+		b.globals[key] = true
+	}
+	return b
+}
+
+func (b *BlackBoard) isGlobal(key int) bool {
+	b.globalMu.RLock()
+	defer b.globalMu.RUnlock()
+	return b.globals[key]
+}
+
+// the nearest parent scope marking key global, b when none does
+func (b *BlackBoard) writeScope(key int) *BlackBoard {
+	for scope := b.parent; scope != nil; scope = scope.parent {
+		if scope.isGlobal(key) {
+			return scope
+		}
+	}
+	return b
+}
+
+// ScopeOf returns the nearest scope having key, nil when none has it
+func (b *BlackBoard) ScopeOf(key int) *BlackBoard {
+	for scope := b; scope != nil; scope = scope.parent {
+		if _, ok := scope.loadLocal(key); ok {
+			return scope
+		}
+	}
+	return nil
+}
+
+// HasLocal tells whether key is set in b itself, not in a parent scope
+func (b *BlackBoard) HasLocal(key int) bool {
+	_, ok := b.loadLocal(key)
+	return ok
+}
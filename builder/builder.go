@@ -0,0 +1,255 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+/*
+ * Package builder creates trees with every parent node wired and every node
+ * wrapped, so preconditions are evaluated and active nodes are propagated:
+ *
+ *	tree, err := bt.Priority("root").
+ *		Child(bt.Sequence("attack").When(canAttack).Leaf(aim, shoot)).
+ *		Child(bt.Terminal("idle", idle)).
+ *		Build()
+ */
+package builder
+
+import (
+	"fmt"
+
+	"github.com/ShionRyuu/gobevtree/node"
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+type CompositeFunc func(parentNode node.IBevNode) node.IBevSelector
+
+type Builder struct {
+	kind      string
+	name      string
+	cond      p.IPrecondition
+	reverse   bool
+	composite CompositeFunc
+	terminal  node.IBevTerminal
+	scorer    node.IScorer
+	children  []*Builder
+	err       error
+}
+
+/*
+ * Composite builders
+ */
+func Composite(kind string, name string, composite CompositeFunc) *Builder {
+	return &Builder{kind: kind, name: name, composite: composite}
+}
+
+func Priority(name string) *Builder {
+	return Composite("PrioritySelector", name, func(parentNode node.IBevNode) node.IBevSelector {
+		return node.NewPrioritySelector(parentNode, nil)
+	})
+}
+
+func NonePriority(name string) *Builder {
+	return Composite("NonePrioritySelector", name, func(parentNode node.IBevNode) node.IBevSelector {
+		return node.NewNonePrioritySelector(parentNode, nil)
+	})
+}
+
+func Sequence(name string) *Builder {
+	return Composite("SequenceSelector", name, func(parentNode node.IBevNode) node.IBevSelector {
+		return node.NewSequenceSelector(parentNode, nil)
+	})
+}
+
+func Parallel(name string) *Builder {
+	return Composite("ParallelSelector", name, func(parentNode node.IBevNode) node.IBevSelector {
+		return node.NewParallelSelector(parentNode, nil)
+	})
+}
+
+func Random(name string) *Builder {
+	return Composite("RandomSelector", name, func(parentNode node.IBevNode) node.IBevSelector {
+		return node.NewRandomSelector(parentNode, nil)
+	})
+}
+
+func Loop(name string, totalLoopCount int) *Builder {
+	b := Composite("LoopSelector", name, func(parentNode node.IBevNode) node.IBevSelector {
+		return node.NewLoopSelector(parentNode, nil, totalLoopCount)
+	})
+	if totalLoopCount < 0 && totalLoopCount != node.ConstInfiniteLoop {
+		b.err = fmt.Errorf("invalid loop count %d", totalLoopCount)
+	}
+	return b
+}
+
+// children are given a scorer with Score
+func Utility(name string, mode node.UtilityMode, momentum float64) *Builder {
+	return Composite("UtilitySelector", name, func(parentNode node.IBevNode) node.IBevSelector {
+		return node.NewUtilitySelector(parentNode, nil).SetMode(mode).SetMomentum(momentum)
+	})
+}
+
+/*
+ * Terminal builder, the terminal is wrapped with NewTerminal unless it already is
+ */
+func Terminal(name string, terminal node.IBevTerminal) *Builder {
+	b := &Builder{kind: "terminal", name: name, terminal: terminal}
+	if terminal == nil {
+		b.err = fmt.Errorf("terminal is nil")
+	}
+	return b
+}
+
+// set the precondition of the node
+func (b *Builder) When(cond p.IPrecondition) *Builder {
+	b.cond = cond
+	return b
+}
+
+// set the scorer of the node, its parent must be a UtilitySelector
+func (b *Builder) Score(scorer node.IScorer) *Builder {
+	b.scorer = scorer
+	return b
+}
+
+// wrap the node with NewReverse
+func (b *Builder) Reverse() *Builder {
+	b.reverse = true
+	return b
+}
+
+func (b *Builder) Child(children ...*Builder) *Builder {
+	if b.terminal != nil {
+		b.setErr(fmt.Errorf("terminal can not have children"))
+	}
+	b.children = append(b.children, children...)
+	return b
+}
+
+// shortcut for Child(Terminal("", terminal)...)
+func (b *Builder) Leaf(terminals ...node.IBevTerminal) *Builder {
+	for _, terminal := range terminals {
+		b.Child(Terminal("", terminal))
+	}
+	return b
+}
+
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (b *Builder) segment(index int) string {
+	if b.name != "" {
+		return b.name
+	}
+	return fmt.Sprintf("%s[%d]", b.kind, index)
+}
+
+/*
+ * Build creates the tree. Composites are created on every call while terminals
+ * are used as given, so a builder holding terminals should be built only once.
+ */
+func (b *Builder) Build() (node.IBevNode, error) {
+	path := b.name
+	if path == "" {
+		path = b.kind
+	}
+	tree, err := b.build(nil, path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, issue := range node.Validate(tree) {
+		if issue.Severity == node.SeverityError {
+			return nil, fmt.Errorf("%s: %s", issue.Path, issue.Message)
+		}
+	}
+	return tree, nil
+}
+
+func (b *Builder) build(parentNode node.IBevNode, path string) (node.IBevNode, error) {
+	if b.err != nil {
+		return nil, fmt.Errorf("%s: %v", path, b.err)
+	}
+
+	var result node.IBevNode
+	if b.terminal != nil {
+		terminal, ok := b.terminal.(*node.BevTerminal)
+		if !ok {
+			terminal = node.NewTerminal(b.terminal)
+		}
+		terminal.Reconstruct(parentNode)
+		if b.cond != nil {
+			terminal.SetNodePrecondition(b.cond)
+		}
+		result = terminal
+	} else {
+		if err := b.checkShape(); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		composite := b.composite(parentNode)
+		utility, isUtility := composite.(*node.UtilitySelector)
+		selector := node.NewSelector(composite)
+		selector.Reconstruct(parentNode)
+		selector.SetNodePrecondition(b.cond)
+		for i, childBuilder := range b.children {
+			if childBuilder == nil {
+				return nil, fmt.Errorf("%s: child %d is nil", path, i)
+			}
+			childPath := path + "/" + childBuilder.segment(i)
+			child, err := childBuilder.build(selector, childPath)
+			if err != nil {
+				return nil, err
+			}
+			selector.AddChildNode(child)
+			if childBuilder.scorer != nil {
+				if !isUtility {
+					return nil, fmt.Errorf("%s: scorer set but %s is not a UtilitySelector", childPath, path)
+				}
+				utility.SetScorer(i, childBuilder.scorer)
+			}
+		}
+		result = selector
+	}
+
+	if b.name != "" {
+		result.SetDebugName(b.name)
+	}
+	if b.reverse {
+		result = node.NewReverse(result)
+	}
+	return result, nil
+}
+
+func (b *Builder) checkShape() error {
+	switch {
+	case len(b.children) > node.ConstMaxChildNodeCnt:
+		return fmt.Errorf("%d children, at most %d allowed", len(b.children), node.ConstMaxChildNodeCnt)
+	case len(b.children) == 0:
+		return fmt.Errorf("%s has no children", b.kind)
+	case b.kind == "LoopSelector" && len(b.children) > 1:
+		return fmt.Errorf("LoopSelector only ticks its first child, got %d", len(b.children))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/ShionRyuu/gobevtree/node"
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type say struct {
+	*node.TerminalNode
+	word string
+}
+
+func newSay(word string) *say {
+	return &say{node.NewTerminalNode(nil, nil), word}
+}
+
+func (this *say) Enter(input interface{}) {
+}
+
+func (this *say) Execute(input interface{}, output interface{}) node.BevRunningStatus {
+	*output.(*[]string) = append(*output.(*[]string), this.word)
+	return node.StateFinish
+}
+
+func (this *say) Exit(input interface{}, exitStatus node.BevRunningStatus) {
+}
+
+func TestBuilder(t *testing.T) {
+	Convey("Built trees are wired and wrapped", t, func() {
+		tree, err := Priority("root").
+			Child(Sequence("attack").When(NewPreconditionFALSE()).Leaf(newSay("aim"), newSay("shoot"))).
+			Child(Sequence("talk").Leaf(newSay("hello"), newSay("bye"))).
+			Build()
+		So(err, ShouldBeNil)
+		So(node.Validate(tree), ShouldBeEmpty)
+
+		var words []string
+		for i := 0; i < 2; i++ {
+			if tree.Evaluate(nil) {
+				tree.Tick(nil, &words)
+			}
+		}
+		So(words, ShouldResemble, []string{"hello", "bye"})
+	})
+
+	Convey("Terminal preconditions and reverse are applied", t, func() {
+		tree, err := Priority("root").
+			Child(Terminal("never", newSay("never")).When(NewPreconditionFALSE())).
+			Child(Terminal("reversed", newSay("reversed")).When(NewPreconditionTRUE()).Reverse()).
+			Child(Terminal("always", newSay("always"))).
+			Build()
+		So(err, ShouldBeNil)
+
+		var words []string
+		if tree.Evaluate(nil) {
+			tree.Tick(nil, &words)
+		}
+		So(words, ShouldResemble, []string{"always"})
+	})
+
+	Convey("Children of utility selectors are given scorers", t, func() {
+		score := func(v float64) node.ScorerFunc {
+			return func(input interface{}) float64 { return v }
+		}
+		tree, err := Utility("root", node.UtilityHighest, 0.1).
+			Child(Terminal("low", newSay("low")).Score(score(0.2))).
+			Child(Terminal("high", newSay("high")).Score(score(0.7))).
+			Build()
+		So(err, ShouldBeNil)
+		So(node.Validate(tree), ShouldBeEmpty)
+
+		var words []string
+		if tree.Evaluate(nil) {
+			tree.Tick(nil, &words)
+		}
+		So(words, ShouldResemble, []string{"high"})
+	})
+
+	Convey("Invalid shapes are errors", t, func() {
+		_, err := Priority("root").Child(Sequence("empty")).Build()
+		So(err.Error(), ShouldEqual, "root/empty: SequenceSelector has no children")
+
+		_, err = Priority("root").Child(Loop("", 1).Leaf(newSay("a"), newSay("b"))).Build()
+		So(err.Error(), ShouldEqual, "root/LoopSelector[0]: LoopSelector only ticks its first child, got 2")
+
+		_, err = Priority("root").Child(Terminal("t", newSay("a")).Child(Sequence("s"))).Build()
+		So(err.Error(), ShouldEqual, "root/t: terminal can not have children")
+
+		_, err = Priority("root").Child(Terminal("t", newSay("a")).Score(node.ScorerFunc(nil))).Build()
+		So(err.Error(), ShouldEqual, "root/t: scorer set but root is not a UtilitySelector")
+
+		shared := newSay("shared")
+		_, err = Priority("root").Leaf(shared, shared).Build()
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,399 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+// Command gobevtree validates, renders and runs behaviour tree definition files.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	"github.com/ShionRyuu/gobevtree/loader"
+	"github.com/ShionRyuu/gobevtree/node"
+	"github.com/ShionRyuu/gobevtree/trace"
+)
+
+const usage = `usage: gobevtree <command> [flags] file
+
+commands:
+  validate   check a tree definition file
+  render     print the tree as ascii, dot or mermaid
+  run        tick the tree for some frames and print the trace
+  stats      print node counts and tree shape
+  replay     step through a trace written by run -record
+  diff       compare the structure of two tree files: diff old new
+  explain    tell which branch the tree selects with a blackboard, and why
+`
+
+var commands = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"validate": runValidate,
+	"render":   runRender,
+	"run":      runRun,
+	"stats":    runStats,
+	"replay":   runReplay,
+	"diff":     runDiff,
+	"explain":  runExplain,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run the command of args and return the exit status, 2 for usage errors
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "gobevtree: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	err := cmd(args[1:], stdout, stderr)
+	var ferr flagError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &ferr):
+		return 2
+	}
+	fmt.Fprintln(stderr, "gobevtree:", err)
+	return 1
+}
+
+// stdin of the interactive replay
+var stdin io.Reader = os.Stdin
+
+func newFlags(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+// error of flag.FlagSet.Parse, the flag set already printed it with the usage
+type flagError struct {
+	err error
+}
+
+func (e flagError) Error() string {
+	return e.err.Error()
+}
+
+func (e flagError) Unwrap() error {
+	return e.err
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return flagError{err}
+	}
+	return nil
+}
+
+// parse flags and return the tree definition named by the only positional argument
+func parseArgs(flags *flag.FlagSet, args []string) (*loader.NodeDef, error) {
+	if err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != 1 {
+		return nil, fmt.Errorf("%s: expect exactly one tree file", flags.Name())
+	}
+	return loader.ParseFile(flags.Arg(0))
+}
+
+func runValidate(args []string, stdout, stderr io.Writer) error {
+	flags := newFlags("validate", stderr)
+	def, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	errs := loader.Check(def)
+	for _, err := range errs {
+		fmt.Fprintln(stdout, "error:", err)
+	}
+	errCount := len(errs)
+	if errCount == 0 {
+		tree, err := loader.Build(def)
+		if err != nil {
+			fmt.Fprintln(stdout, "error:", err)
+			errCount++
+		} else {
+			for _, issue := range node.Validate(tree) {
+				fmt.Fprintln(stdout, issue)
+				if issue.Severity == node.SeverityError {
+					errCount++
+				}
+			}
+		}
+	}
+	if errCount > 0 {
+		return fmt.Errorf("%s: %d error(s)", flags.Arg(0), errCount)
+	}
+	fmt.Fprintln(stdout, flags.Arg(0), "ok")
+	return nil
+}
+
+func runRender(args []string, stdout, stderr io.Writer) error {
+	flags := newFlags("render", stderr)
+	format := flags.String("format", "ascii", "output format: ascii, dot or mermaid")
+	def, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	render, ok := renderers[*format]
+	if !ok {
+		return fmt.Errorf("render: unknown format %q", *format)
+	}
+	render(stdout, def)
+	return nil
+}
+
+var errorPolicies = map[string]node.ErrorPolicy{
+	"false":  node.ErrorAsFalse,
+	"report": node.ErrorReport,
+	"fail":   node.ErrorFailBranch,
+}
+
+func runRun(args []string, stdout, stderr io.Writer) error {
+	flags := newFlags("run", stderr)
+	frames := flags.Int("frames", 10, "number of frames to tick")
+	boardFile := flags.String("board", "", "json file used to seed the input blackboard")
+	record := flags.String("record", "", "write a trace of the run to this file, see replay")
+	state := flags.Bool("state", false, "print the tree with its run state after every frame")
+	errorPolicy := flags.String("errors", "report", "what precondition errors do: false, report or fail")
+	def, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	policy, ok := errorPolicies[*errorPolicy]
+	if !ok {
+		return fmt.Errorf("run: unknown error policy %q", *errorPolicy)
+	}
+
+	root, err := loader.Build(def)
+	if err != nil {
+		return err
+	}
+	inboard := bb.NewBlackboard()
+	if *boardFile != "" {
+		if err := loader.SeedBlackboardFile(inboard, *boardFile); err != nil {
+			return err
+		}
+	}
+
+	tree := node.NewBevTree(root).SetErrorPolicy(policy)
+	var recorder *trace.Recorder
+	if *record != "" {
+		f, err := os.Create(*record)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		recorder = trace.NewRecorder(f, tree).WatchBlackboard(inboard)
+	}
+
+	for i := 0; i < *frames; i++ {
+		fmt.Fprintf(stdout, "frame %d\n", i)
+		status := tree.Update(inboard, stdout)
+		fmt.Fprintln(stdout, "  status", status)
+		if err := tree.Err(); err != nil {
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Fprintln(stdout, "  error", line)
+			}
+		}
+		if *state {
+			node.RenderTree(stdout, root, node.RenderOptions{RunState: true})
+		}
+		if recorder != nil {
+			if err := recorder.EndFrame(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func runReplay(args []string, stdout, stderr io.Writer) error {
+	flags := newFlags("replay", stderr)
+	start := flags.Int("frame", 0, "position of the first frame shown")
+	all := flags.Bool("all", false, "print every frame instead of reading commands from stdin")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("replay: expect exactly one trace file")
+	}
+
+	t, err := trace.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	player := trace.NewPlayer(t)
+	if !player.Seek(*start) {
+		return fmt.Errorf("replay: no frame at %d, trace has %d", *start, player.Len())
+	}
+	if *all {
+		for ok := true; ok; ok = player.Next() {
+			player.Render(stdout)
+		}
+		return nil
+	}
+
+	player.Render(stdout)
+	scanner := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprint(stdout, "(n)ext (p)rev (g)oto N (q)uit> ")
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		var cmd string
+		var pos int
+		fmt.Sscan(scanner.Text(), &cmd, &pos)
+		moved := true
+		switch cmd {
+		case "", "n":
+			moved = player.Next()
+		case "p":
+			moved = player.Prev()
+		case "g":
+			moved = player.Seek(pos)
+		case "q":
+			return nil
+		default:
+			fmt.Fprintf(stdout, "unknown command %q\n", cmd)
+			continue
+		}
+		if !moved {
+			fmt.Fprintln(stdout, "no such frame")
+			continue
+		}
+		player.Render(stdout)
+	}
+}
+
+func runDiff(args []string, stdout, stderr io.Writer) error {
+	flags := newFlags("diff", stderr)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("diff: expect an old and a new tree file")
+	}
+
+	var trees [2]node.IBevNode
+	for i := range trees {
+		def, err := loader.ParseFile(flags.Arg(i))
+		if err != nil {
+			return err
+		}
+		if trees[i], err = loader.Build(def); err != nil {
+			return fmt.Errorf("%s: %v", flags.Arg(i), err)
+		}
+	}
+
+	changes := node.Diff(trees[0], trees[1])
+	for _, change := range changes {
+		fmt.Fprintln(stdout, change)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "no change")
+	}
+	return nil
+}
+
+func runStats(args []string, stdout, stderr io.Writer) error {
+	flags := newFlags("stats", stderr)
+	def, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	var total, leaves, conds, maxDepth, maxFanOut int
+	types := map[string]int{}
+	def.Walk(func(def *loader.NodeDef, path string, depth int) {
+		total++
+		types[def.Type]++
+		if len(def.Children) == 0 {
+			leaves++
+		}
+		if def.Precondition != nil {
+			conds++
+		}
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		if len(def.Children) > maxFanOut {
+			maxFanOut = len(def.Children)
+		}
+	})
+
+	fmt.Fprintf(stdout, "nodes:         %d\n", total)
+	fmt.Fprintf(stdout, "leaves:        %d\n", leaves)
+	fmt.Fprintf(stdout, "preconditions: %d\n", conds)
+	fmt.Fprintf(stdout, "depth:         %d\n", maxDepth+1)
+	fmt.Fprintf(stdout, "max children:  %d\n", maxFanOut)
+	for _, typ := range sortedKeys(types) {
+		fmt.Fprintf(stdout, "  %-12s %d\n", typ, types[typ])
+	}
+	return nil
+}
+
+func runExplain(args []string, stdout, stderr io.Writer) error {
+	flags := newFlags("explain", stderr)
+	boardFile := flags.String("board", "", "json file used to seed the input blackboard")
+	asJSON := flags.Bool("json", false, "print the explanation as json")
+	def, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	root, err := loader.Build(def)
+	if err != nil {
+		return err
+	}
+	inboard := bb.NewBlackboard()
+	if *boardFile != "" {
+		if err := loader.SeedBlackboardFile(inboard, *boardFile); err != nil {
+			return err
+		}
+	}
+
+	explanation := node.Explain(root, inboard)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(explanation)
+	}
+	return explanation.WriteText(stdout)
+}
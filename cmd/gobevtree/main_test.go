@@ -0,0 +1,107 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"type": "priority", "children": [{"type": "nosuchnode"}]}`), 0644)
+	broken := filepath.Join(dir, "broken.json")
+	os.WriteFile(broken, []byte(`{"type": "priority",`), 0644)
+	traceFile := filepath.Join(dir, "run.trace")
+
+	for _, c := range []struct {
+		name   string
+		args   []string
+		status int
+		stdout []string // substrings of stdout
+		stderr []string // substrings of stderr
+	}{
+		{"no command", nil, 2, nil, []string{"usage: gobevtree"}},
+		{"unknown command", []string{"frobnicate"}, 2, nil, []string{`unknown command "frobnicate"`}},
+		{"validate", []string{"validate", "testdata/simple.json"}, 0, []string{"testdata/simple.json ok"}, nil},
+		{"validate unknown node type", []string{"validate", bad}, 1, []string{"error:"}, []string{"1 error(s)"}},
+		{"validate broken json", []string{"validate", broken}, 1, nil, []string{"broken.json: unexpected EOF"}},
+		{"validate missing file", []string{"validate", "testdata/nofile.json"}, 1, nil, []string{"no such file"}},
+		{"validate without file", []string{"validate"}, 1, nil, []string{"validate: expect exactly one tree file"}},
+		{"unknown flag", []string{"validate", "-x", "testdata/simple.json"}, 2, nil, []string{"flag provided but not defined: -x", "Usage of validate"}},
+		{"bad flag value", []string{"run", "-frames", "many", "testdata/simple.json"}, 2, nil, []string{"invalid value \"many\""}},
+		{"help", []string{"run", "-h"}, 0, nil, []string{"-frames"}},
+		{"run", []string{"run", "-frames", "2", "-board", "testdata/board.json", "testdata/simple.json"}, 0,
+			[]string{"frame 0\n  action say11\n  status executing\nframe 1\n  wait wait 0/2\n  status executing\n"}, nil},
+		{"run unknown error policy", []string{"run", "-errors", "nope", "testdata/simple.json"}, 1, nil, []string{`unknown error policy "nope"`}},
+		{"run broken definition", []string{"run", broken}, 1, nil, []string{"broken.json"}},
+		{"run and record", []string{"run", "-frames", "1", "-record", traceFile, "testdata/simple.json"}, 0, []string{"frame 0"}, nil},
+		{"replay", []string{"replay", "-all", traceFile}, 0, []string{"frame 0 (1/1)", "root [evaluate=true tick=finish]"}, nil},
+		{"replay missing frame", []string{"replay", "-all", "-frame", "5", traceFile}, 1, nil, []string{"no frame at 5"}},
+		{"render", []string{"render", "testdata/simple.json"}, 0, []string{"|— priority root", "|— sequence seq <less(first=1, second=2)>"}, nil},
+		{"stats", []string{"stats", "testdata/simple.json"}, 0, []string{"nodes:         8", "depth:         3"}, nil},
+		{"diff", []string{"diff", "testdata/simple.json", "testdata/simple_v2.json"}, 0, []string{"type: root/rand: RandomSelector -> NonePrioritySelector"}, nil},
+		{"diff same tree", []string{"diff", "testdata/simple.json", "testdata/simple.json"}, 0, []string{"no change"}, nil},
+		{"diff one file", []string{"diff", "testdata/simple.json"}, 1, nil, []string{"expect an old and a new tree file"}},
+		{"explain", []string{"explain", "-board", "testdata/board.json", "testdata/simple.json"}, 0, []string{"root (PrioritySelector): true, selected root/seq"}, nil},
+		{"explain json", []string{"explain", "-json", "testdata/simple.json"}, 0, []string{`"path": "root"`}, nil},
+	} {
+		Convey(c.name, t, func() {
+			var stdout, stderr bytes.Buffer
+			So(run(c.args, &stdout, &stderr), ShouldEqual, c.status)
+			for _, want := range c.stdout {
+				So(stdout.String(), ShouldContainSubstring, want)
+			}
+			for _, want := range c.stderr {
+				So(stderr.String(), ShouldContainSubstring, want)
+			}
+			if c.stderr == nil {
+				So(stderr.String(), ShouldBeEmpty)
+			}
+		})
+	}
+}
+
+func TestInteractiveReplay(t *testing.T) {
+	Convey("replay reads commands from stdin", t, func() {
+		traceFile := filepath.Join(t.TempDir(), "run.trace")
+		So(run([]string{"run", "-frames", "2", "-record", traceFile, "testdata/simple.json"}, &bytes.Buffer{}, &bytes.Buffer{}), ShouldEqual, 0)
+
+		defer func(r io.Reader) { stdin = r }(stdin)
+		stdin = strings.NewReader("n\nn\nx\ng 0\nq\n")
+		var stdout bytes.Buffer
+		So(run([]string{"replay", traceFile}, &stdout, &bytes.Buffer{}), ShouldEqual, 0)
+		So(stdout.String(), ShouldContainSubstring, "frame 1 (2/2)")
+		So(stdout.String(), ShouldContainSubstring, "no such frame")
+		So(stdout.String(), ShouldContainSubstring, `unknown command "x"`)
+		So(strings.Count(stdout.String(), "frame 0 (1/2)"), ShouldEqual, 2)
+	})
+}
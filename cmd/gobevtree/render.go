@@ -0,0 +1,130 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ShionRyuu/gobevtree/loader"
+)
+
+var renderers = map[string]func(w io.Writer, def *loader.NodeDef){
+	"ascii":   renderASCII,
+	"dot":     renderDOT,
+	"mermaid": renderMermaid,
+}
+
+func renderASCII(w io.Writer, def *loader.NodeDef) {
+	def.Walk(func(def *loader.NodeDef, path string, depth int) {
+		fmt.Fprintf(w, "%s|— %s\n", strings.Repeat("    ", depth), nodeLabel(def))
+	})
+}
+
+func renderDOT(w io.Writer, def *loader.NodeDef) {
+	ids := nodeIds(def)
+	fmt.Fprintln(w, "digraph tree {")
+	fmt.Fprintln(w, "  node [shape=box];")
+	def.Walk(func(def *loader.NodeDef, path string, depth int) {
+		fmt.Fprintf(w, "  %s [label=%q];\n", ids[def], nodeLabel(def))
+		for _, child := range def.Children {
+			if child != nil {
+				fmt.Fprintf(w, "  %s -> %s;\n", ids[def], ids[child])
+			}
+		}
+	})
+	fmt.Fprintln(w, "}")
+}
+
+func renderMermaid(w io.Writer, def *loader.NodeDef) {
+	ids := nodeIds(def)
+	fmt.Fprintln(w, "graph TD")
+	def.Walk(func(def *loader.NodeDef, path string, depth int) {
+		label := strings.Replace(nodeLabel(def), `"`, "#quot;", -1)
+		fmt.Fprintf(w, "  %s[\"%s\"]\n", ids[def], label)
+		for _, child := range def.Children {
+			if child != nil {
+				fmt.Fprintf(w, "  %s --> %s\n", ids[def], ids[child])
+			}
+		}
+	})
+}
+
+func nodeIds(def *loader.NodeDef) map[*loader.NodeDef]string {
+	ids := map[*loader.NodeDef]string{}
+	def.Walk(func(def *loader.NodeDef, path string, depth int) {
+		ids[def] = fmt.Sprintf("n%d", len(ids))
+	})
+	return ids
+}
+
+// "type name <precondition>", reversed nodes are prefixed with "!"
+func nodeLabel(def *loader.NodeDef) string {
+	label := def.Type
+	if def.Reverse {
+		label = "!" + label
+	}
+	if def.Name != "" {
+		label += " " + def.Name
+	}
+	if def.Precondition != nil {
+		label += " <" + condLabel(def.Precondition) + ">"
+	}
+	return label
+}
+
+func condLabel(def *loader.CondDef) string {
+	if def == nil {
+		return "null"
+	}
+	var parts []string
+	for _, arg := range def.Args {
+		parts = append(parts, condLabel(arg))
+	}
+	for _, k := range sortedKeys(def.Params) {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, def.Params[k]))
+	}
+	if len(parts) == 0 {
+		return def.Type
+	}
+	return def.Type + "(" + strings.Join(parts, ", ") + ")"
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]int:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]interface{}:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
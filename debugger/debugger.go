@@ -0,0 +1,375 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+/*
+ * Package debugger pauses a tree in the middle of an Update. The debugger is a
+ * listener of the tree, when a breakpoint is hit its callback blocks the
+ * goroutine running Update, so the tree stops between two child ticks. Another
+ * goroutine inspects the paused tree and resumes it with Step or Continue:
+ *
+ *	d := debugger.Attach(tree, board)
+ *	d.BreakOnEnter("root/attack/shoot")
+ *	go tree.Update(board, nil)
+ *	pause, _ := d.WaitPause(time.Second)
+ *	d.Inspect(func(root node.IBevNode) { ... })
+ *	d.Step()
+ */
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	"github.com/ShionRyuu/gobevtree/node"
+)
+
+var (
+	ErrNotPaused = errors.New("tree is not paused")
+)
+
+/*
+ * BreakpointKind
+ */
+const (
+	BreakEnter BreakpointKind = iota
+	BreakStatus
+	BreakKey
+)
+
+type BreakpointKind int
+
+func (kind BreakpointKind) String() string {
+	switch kind {
+	case BreakEnter:
+		return "enter"
+	case BreakStatus:
+		return "status"
+	case BreakKey:
+		return "key"
+	}
+	return fmt.Sprintf("breakpoint(%d)", int(kind))
+}
+
+// Breakpoint on a node path for BreakEnter and BreakStatus, on a blackboard key for BreakKey
+type Breakpoint struct {
+	Id     int
+	Kind   BreakpointKind
+	Path   string
+	Status node.BevRunningStatus
+	Key    int
+}
+
+func (b Breakpoint) String() string {
+	switch b.Kind {
+	case BreakEnter:
+		return fmt.Sprintf("#%d enter %s", b.Id, b.Path)
+	case BreakStatus:
+		return fmt.Sprintf("#%d %s returns %s", b.Id, b.Path, b.Status)
+	case BreakKey:
+		return fmt.Sprintf("#%d key %d changes", b.Id, b.Key)
+	}
+	return fmt.Sprintf("#%d %s", b.Id, b.Kind)
+}
+
+// Pause tells where and why the tree stopped, Breakpoint is nil when stepping or interrupted
+type Pause struct {
+	Frame      int
+	Path       string
+	Event      string
+	Status     node.BevRunningStatus
+	Breakpoint *Breakpoint
+}
+
+func (p Pause) String() string {
+	reason := "step"
+	if p.Breakpoint != nil {
+		reason = p.Breakpoint.String()
+	}
+	if p.Event == "tick" {
+		return fmt.Sprintf("frame %d: %s %s returned %s (%s)", p.Frame, p.Event, p.Path, p.Status, reason)
+	}
+	return fmt.Sprintf("frame %d: %s %s (%s)", p.Frame, p.Event, p.Path, reason)
+}
+
+/*
+ * Debugger
+ */
+type Debugger struct {
+	node.BevListener
+	tree  *node.BevTree
+	board *bb.BlackBoard
+	paths map[node.IBevNode]string
+
+	mu          sync.Mutex
+	breakpoints []Breakpoint
+	nextId      int
+	keyValues   map[int]interface{}
+	stepping    bool
+	pause       *Pause
+	pausedCh    chan struct{}
+	resume      chan bool
+	detached    bool
+}
+
+// Attach starts listening to tree, board is needed by BreakOnKey and may be nil otherwise
+func Attach(tree *node.BevTree, board *bb.BlackBoard) *Debugger {
+	d := &Debugger{
+		tree:      tree,
+		board:     board,
+		paths:     node.Paths(tree.GetRoot()),
+		keyValues: map[int]interface{}{},
+		pausedCh:  make(chan struct{}),
+		resume:    make(chan bool),
+	}
+	tree.AddListener(d)
+	return d
+}
+
+// Detach removes the breakpoints and resumes a paused tree, the listener is
+// removed by the goroutine updating the tree at its next callback
+func (d *Debugger) Detach() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = nil
+	d.stepping = false
+	d.detached = true
+	if d.pause != nil {
+		d.release(false)
+	}
+}
+
+// resume the goroutine blocked in check, d.mu must be held
+func (d *Debugger) release(step bool) {
+	d.pause = nil
+	d.pausedCh = make(chan struct{})
+	d.stepping = step
+	d.resume <- step
+}
+
+func (d *Debugger) add(b Breakpoint) Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextId++
+	b.Id = d.nextId
+	d.breakpoints = append(d.breakpoints, b)
+	if b.Kind == BreakKey && d.board != nil {
+		value, _ := d.board.GetValueAsInterface(b.Key)
+		d.keyValues[b.Key] = value
+	}
+	return b
+}
+
+// pause after the node at path is entered
+func (d *Debugger) BreakOnEnter(path string) Breakpoint {
+	return d.add(Breakpoint{Kind: BreakEnter, Path: path})
+}
+
+// pause after the node at path is ticked and returns status
+func (d *Debugger) BreakOnStatus(path string, status node.BevRunningStatus) Breakpoint {
+	return d.add(Breakpoint{Kind: BreakStatus, Path: path, Status: status})
+}
+
+// pause after the tick of a terminal which changed the value of key
+func (d *Debugger) BreakOnKey(key int) Breakpoint {
+	return d.add(Breakpoint{Kind: BreakKey, Key: key})
+}
+
+func (d *Debugger) Clear(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, b := range d.breakpoints {
+		if b.Id == id {
+			d.breakpoints = append(d.breakpoints[:i:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Debugger) ClearAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = nil
+}
+
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Breakpoint(nil), d.breakpoints...)
+}
+
+// Interrupt pauses the tree at the next node it enters or ticks
+func (d *Debugger) Interrupt() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stepping = true
+}
+
+// Paused returns where the tree is paused
+func (d *Debugger) Paused() (Pause, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.pause == nil {
+		return Pause{}, false
+	}
+	return *d.pause, true
+}
+
+// WaitPause blocks until the tree is paused or timeout elapses
+func (d *Debugger) WaitPause(timeout time.Duration) (Pause, bool) {
+	d.mu.Lock()
+	if d.pause != nil {
+		defer d.mu.Unlock()
+		return *d.pause, true
+	}
+	ch := d.pausedCh
+	d.mu.Unlock()
+
+	select {
+	case <-ch:
+		return d.Paused()
+	case <-time.After(timeout):
+		return Pause{}, false
+	}
+}
+
+// Step resumes the tree until the next node is entered or ticked
+func (d *Debugger) Step() error {
+	return d.resumeTree(true)
+}
+
+// Continue resumes the tree until the next breakpoint
+func (d *Debugger) Continue() error {
+	return d.resumeTree(false)
+}
+
+func (d *Debugger) resumeTree(step bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.pause == nil {
+		return ErrNotPaused
+	}
+	d.release(step)
+	return nil
+}
+
+// Inspect runs f with the root of the paused tree, it is safe as the tree can not run meanwhile
+func (d *Debugger) Inspect(f func(root node.IBevNode)) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.pause == nil {
+		return ErrNotPaused
+	}
+	f(d.tree.GetRoot())
+	return nil
+}
+
+/*
+ * Listener callbacks, they run in the goroutine updating the tree
+ */
+func (d *Debugger) OnEnter(n node.IBevNode) {
+	d.check(n, "enter", 0)
+}
+
+func (d *Debugger) OnTick(n node.IBevNode, status node.BevRunningStatus) {
+	d.check(n, "tick", status)
+}
+
+func (d *Debugger) path(n node.IBevNode) string {
+	path, ok := d.paths[n]
+	if !ok {
+		d.paths = node.Paths(d.tree.GetRoot())
+		if path, ok = d.paths[n]; !ok {
+			path = node.NodeLabel(n)
+		}
+	}
+	return path
+}
+
+func (d *Debugger) check(n node.IBevNode, event string, status node.BevRunningStatus) {
+	d.mu.Lock()
+	if d.detached {
+		d.mu.Unlock()
+		d.tree.RemoveListener(d)
+		return
+	}
+	if !d.stepping && len(d.breakpoints) == 0 {
+		d.mu.Unlock()
+		return
+	}
+
+	path := d.path(n)
+	var hit *Breakpoint
+	for i := range d.breakpoints {
+		b := &d.breakpoints[i]
+		matched := false
+		switch b.Kind {
+		case BreakEnter:
+			matched = event == "enter" && b.Path == path
+		case BreakStatus:
+			matched = event == "tick" && b.Path == path && b.Status == status
+		case BreakKey:
+			if event == "tick" && d.board != nil {
+				value, _ := d.board.GetValueAsInterface(b.Key)
+				matched = !reflect.DeepEqual(value, d.keyValues[b.Key])
+				d.keyValues[b.Key] = value
+			}
+		}
+		if matched && hit == nil {
+			hit = b
+		}
+	}
+	if hit == nil && !d.stepping {
+		d.mu.Unlock()
+		return
+	}
+
+	pause := &Pause{Frame: d.tree.GetFrame(), Path: path, Event: event, Status: status}
+	if hit != nil {
+		b := *hit
+		pause.Breakpoint = &b
+	}
+	d.pause = pause
+	d.stepping = false
+	close(d.pausedCh)
+	d.mu.Unlock()
+
+	<-d.resume
+}
+
+// sorted paths of the nodes known to the debugger, handy to set breakpoints
+func (d *Debugger) NodePaths() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var paths []string
+	for _, path := range d.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,127 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package debugger
+
+import (
+	"testing"
+	"time"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	bt "github.com/ShionRyuu/gobevtree/builder"
+	"github.com/ShionRyuu/gobevtree/node"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// increment key 1 of the blackboard
+type incr struct {
+	*node.TerminalNode
+}
+
+func (this *incr) Enter(input interface{}) {
+}
+
+func (this *incr) Execute(input interface{}, output interface{}) node.BevRunningStatus {
+	board := input.(*bb.BlackBoard)
+	n, _ := board.GetValueAsInt(1)
+	board.SetValueAsInt(1, n+1)
+	return node.StateFinish
+}
+
+func (this *incr) Exit(input interface{}, exitStatus node.BevRunningStatus) {
+}
+
+func newTree() (*node.BevTree, *bb.BlackBoard) {
+	root, err := bt.Sequence("root").
+		Child(bt.Terminal("first", &incr{node.NewTerminalNode(nil, nil)})).
+		Child(bt.Terminal("second", &incr{node.NewTerminalNode(nil, nil)})).
+		Build()
+	if err != nil {
+		panic(err)
+	}
+	return node.NewBevTree(root), bb.NewBlackboard()
+}
+
+func TestDebugger(t *testing.T) {
+	Convey("Breakpoints pause the tree which is then stepped", t, func() {
+		tree, board := newTree()
+		d := Attach(tree, board)
+		d.BreakOnEnter("root/second")
+
+		done := make(chan node.BevRunningStatus)
+		go func() {
+			tree.Update(board, nil)
+			done <- tree.Update(board, nil)
+		}()
+
+		pause, ok := d.WaitPause(time.Second)
+		So(ok, ShouldBeTrue)
+		So(pause.String(), ShouldEqual, "frame 1: enter root/second (#1 enter root/second)")
+
+		var value int
+		So(d.Inspect(func(root node.IBevNode) {
+			value, _ = board.GetValueAsInt(1)
+		}), ShouldBeNil)
+		So(value, ShouldEqual, 1)
+
+		So(d.Step(), ShouldBeNil)
+		pause, ok = d.WaitPause(time.Second)
+		So(ok, ShouldBeTrue)
+		So(pause.String(), ShouldEqual, "frame 1: tick root/second returned finish (step)")
+
+		So(d.Step(), ShouldBeNil)
+		pause, _ = d.WaitPause(time.Second)
+		So(pause.Path, ShouldEqual, "root")
+
+		So(d.Continue(), ShouldBeNil)
+		So(<-done, ShouldEqual, node.StateFinish)
+		So(d.Continue(), ShouldEqual, ErrNotPaused)
+	})
+
+	Convey("Status and blackboard breakpoints", t, func() {
+		tree, board := newTree()
+		d := Attach(tree, board)
+		d.BreakOnStatus("root", node.StateExecuting)
+		key := d.BreakOnKey(1)
+
+		done := make(chan bool)
+		go func() {
+			tree.Update(board, nil)
+			done <- true
+		}()
+
+		pause, _ := d.WaitPause(time.Second)
+		So(pause.Path, ShouldEqual, "root/first")
+		So(pause.Breakpoint.Id, ShouldEqual, key.Id)
+
+		So(d.Clear(key.Id), ShouldBeTrue)
+		So(d.Continue(), ShouldBeNil)
+		pause, _ = d.WaitPause(time.Second)
+		So(pause.String(), ShouldEqual, "frame 0: tick root returned executing (#1 root returns executing)")
+
+		d.Detach()
+		<-done
+		_, ok := d.WaitPause(10 * time.Millisecond)
+		So(ok, ShouldBeFalse)
+	})
+}
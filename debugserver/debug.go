@@ -0,0 +1,141 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package debugserver
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ShionRyuu/gobevtree/debugger"
+	"github.com/ShionRyuu/gobevtree/node"
+)
+
+// DebugView is the state of the debugger of an agent, Tree is rendered only while paused
+type DebugView struct {
+	Paused      bool     `json:"paused"`
+	Pause       string   `json:"pause,omitempty"`
+	Breakpoints []string `json:"breakpoints"`
+	Tree        string   `json:"tree,omitempty"`
+}
+
+func debugView(d *debugger.Debugger) DebugView {
+	view := DebugView{Breakpoints: []string{}}
+	for _, b := range d.Breakpoints() {
+		view.Breakpoints = append(view.Breakpoints, b.String())
+	}
+	if pause, ok := d.Paused(); ok {
+		view.Paused, view.Pause = true, pause.String()
+		d.Inspect(func(root node.IBevNode) {
+			view.Tree = node.SprintTree(root, node.RenderOptions{ASCII: true, RunState: true})
+		})
+	}
+	return view
+}
+
+func parseStatus(s string) (node.BevRunningStatus, bool) {
+	for _, status := range []node.BevRunningStatus{node.StateExecuting, node.StateFinish, node.StateTransition} {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+/*
+ * serveDebug handles the debugger routes, all of them take the name of the agent:
+ *	GET  /debug.json                   DebugView of the agent
+ *	POST /break?node=PATH&on=enter     pause when the node is entered
+ *	POST /break?node=PATH&on=STATUS    pause when the node returns executing, finish or transition
+ *	POST /break?key=KEY                pause when a tick changes the blackboard key
+ *	POST /clear[?id=ID]                remove a breakpoint, all of them without id
+ *	POST /interrupt                    pause at the next node
+ *	POST /step                         resume until the next node
+ *	POST /continue                     resume until the next breakpoint
+ * POST routes answer with the DebugView too.
+ */
+func (s *Server) serveDebug(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("name")
+	d, ok := s.Debugger(name)
+	if !ok {
+		http.Error(w, "unknown agent "+strconv.Quote(name), http.StatusNotFound)
+		return
+	}
+	if r.URL.Path == "/debug.json" {
+		writeJSON(w, debugView(d))
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	switch r.URL.Path {
+	case "/break":
+		if key := query.Get("key"); key != "" {
+			k, perr := strconv.Atoi(key)
+			if perr != nil {
+				http.Error(w, "bad key "+strconv.Quote(key), http.StatusBadRequest)
+				return
+			}
+			d.BreakOnKey(k)
+			break
+		}
+		path, on := query.Get("node"), query.Get("on")
+		if path == "" {
+			http.Error(w, "missing node or key", http.StatusBadRequest)
+			return
+		}
+		if on == "" || on == "enter" {
+			d.BreakOnEnter(path)
+		} else if status, ok := parseStatus(on); ok {
+			d.BreakOnStatus(path, status)
+		} else {
+			http.Error(w, "bad event "+strconv.Quote(on), http.StatusBadRequest)
+			return
+		}
+	case "/clear":
+		if id := query.Get("id"); id != "" {
+			n, _ := strconv.Atoi(id)
+			if !d.Clear(n) {
+				http.Error(w, "unknown breakpoint "+strconv.Quote(id), http.StatusNotFound)
+				return
+			}
+		} else {
+			d.ClearAll()
+		}
+	case "/interrupt":
+		d.Interrupt()
+	case "/step":
+		err = d.Step()
+	case "/continue":
+		err = d.Continue()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, debugView(d))
+}
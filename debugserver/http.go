@@ -0,0 +1,179 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package debugserver
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+/*
+ * ServeHTTP routes, relative to where the server is mounted:
+ *	/                      html list of agents
+ *	/agents.json           names of the agents
+ *	/agent?name=NAME       html page of an agent, refreshed every second
+ *	/agent.json?name=NAME  AgentView of an agent
+ *	/debug.json?name=NAME  DebugView of an agent, see serveDebug for the other debugger routes
+ */
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "", "/":
+		s.serveIndex(w, r)
+	case "/agents.json":
+		writeJSON(w, s.Agents())
+	case "/agent":
+		if view, ok := s.lookup(w, r); ok {
+			debug := DebugView{}
+			if d, ok := s.Debugger(view.Name); ok {
+				debug = debugView(d)
+			}
+			s.serveAgent(w, view, debug)
+		}
+	case "/agent.json":
+		if view, ok := s.lookup(w, r); ok {
+			writeJSON(w, view)
+		}
+	case "/debug.json", "/break", "/clear", "/interrupt", "/step", "/continue":
+		s.serveDebug(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (AgentView, bool) {
+	name := r.URL.Query().Get("name")
+	view, ok := s.View(name)
+	if !ok {
+		http.Error(w, "unknown agent "+strconv.Quote(name), http.StatusNotFound)
+	}
+	return view, ok
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><title>gobevtree agents</title></head>
+<body>
+<h1>Agents</h1>
+<ul>
+{{range .}}<li><a href="agent?name={{.}}">{{.}}</a> (<a href="agent.json?name={{.}}">json</a>)</li>
+{{else}}<li>no agent registered</li>
+{{end}}</ul>
+</body></html>
+`))
+
+var agentTemplate = template.Must(template.New("agent").Parse(`<!DOCTYPE html>
+<html><head>
+<title>{{.Name}} - gobevtree</title>
+<meta http-equiv="refresh" content="1">
+<style>
+body { font-family: monospace; }
+.active { font-weight: bold; color: #06c; }
+.failed { color: #999; }
+.finish { color: #080; }
+.transition { color: #c60; }
+</style>
+</head>
+<body>
+<p><a href="./">agents</a> / <a href="agent.json?name={{.Name}}">json</a></p>
+<h1>{{.Name}} frame {{.Frame}}</h1>
+<h2>Tree</h2>
+<pre>
+{{- range .Nodes}}
+<span class="{{.Class}}">{{.Indent}}|— {{.Label}} ({{.Type}}){{if .Evaluated}} evaluate={{.Result}}{{end}}{{if .Score}} score={{.Score}}{{end}}{{if .Status}} {{.Status}}{{end}}{{if .Active}} *{{end}}</span>
+{{- end}}
+</pre>
+<h2>Active path</h2>
+<ol>{{range .ActivePath}}<li>{{.}}</li>{{end}}</ol>
+<h2>Debugger</h2>
+<p>{{if .Debug.Paused}}paused at {{.Debug.Pause}}{{else}}running{{end}}</p>
+<ul>{{range .Debug.Breakpoints}}<li>{{.}}</li>{{end}}</ul>
+{{if .Debug.Paused}}<pre>{{.Debug.Tree}}</pre>{{end}}
+<h2>Blackboard</h2>
+<table>
+{{range .Blackboard}}<tr><td>{{.Key}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+type nodeRow struct {
+	NodeView
+	Indent string
+	Class  string
+}
+
+type boardRow struct {
+	Key   string
+	Value string
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	indexTemplate.Execute(w, s.Agents())
+}
+
+func (s *Server) serveAgent(w http.ResponseWriter, view AgentView, debug DebugView) {
+	data := struct {
+		AgentView
+		Nodes      []nodeRow
+		Blackboard []boardRow
+		Debug      DebugView
+	}{AgentView: view, Debug: debug}
+
+	for _, n := range view.Nodes {
+		row := nodeRow{NodeView: n}
+		for i := 0; i < n.Depth; i++ {
+			row.Indent += "    "
+		}
+		switch {
+		case n.Active:
+			row.Class = "active"
+		case n.Evaluated && !n.Result:
+			row.Class = "failed"
+		default:
+			row.Class = n.Status
+		}
+		data.Nodes = append(data.Nodes, row)
+	}
+
+	for k, v := range view.Blackboard {
+		data.Blackboard = append(data.Blackboard, boardRow{k, string(v)})
+	}
+	sort.Slice(data.Blackboard, func(i, j int) bool {
+		a, _ := strconv.Atoi(data.Blackboard[i].Key)
+		b, _ := strconv.Atoi(data.Blackboard[j].Key)
+		return a < b
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	agentTemplate.Execute(w, data)
+}
@@ -0,0 +1,247 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+/*
+ * Package debugserver is an opt-in net/http handler to watch registered agents:
+ *
+ *	server := debugserver.NewServer()
+ *	server.Register("npc-1", tree, board)
+ *	http.Handle("/debug/bevtree/", http.StripPrefix("/debug/bevtree", server))
+ *
+ * The state of an agent is captured at the end of every Update of its tree, in
+ * the goroutine updating it, so pages never read a tree while it is ticked.
+ */
+package debugserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	"github.com/ShionRyuu/gobevtree/debugger"
+	"github.com/ShionRyuu/gobevtree/node"
+)
+
+// NodeView is the state of a node at the end of a frame
+type NodeView struct {
+	Path      string `json:"path"`
+	Label     string `json:"label"`
+	Type      string `json:"type"`
+	Depth     int    `json:"depth"`
+	Evaluated bool   `json:"evaluated"`
+	Result    bool   `json:"result"`
+	Status    string `json:"status,omitempty"`
+	Active    bool   `json:"active"`
+	// score given by the parent when it is a UtilitySelector, omitted when NaN or infinite
+	Score *float64 `json:"score,omitempty"`
+}
+
+// AgentView is the state of an agent at the end of a frame
+type AgentView struct {
+	Name       string                     `json:"name"`
+	Frame      int                        `json:"frame"`
+	Nodes      []NodeView                 `json:"nodes"`
+	ActivePath []string                   `json:"activePath"`
+	Blackboard map[string]json.RawMessage `json:"blackboard"`
+}
+
+/*
+ * Server keeps the agents and serves their views
+ */
+type Server struct {
+	mu     sync.RWMutex
+	agents map[string]*agent
+}
+
+func NewServer() *Server {
+	return &Server{agents: map[string]*agent{}}
+}
+
+// Register starts capturing tree and board, board may be nil. Like AddListener
+// it must not be called while the tree is being updated by another goroutine
+func (s *Server) Register(name string, tree *node.BevTree, board *bb.BlackBoard) {
+	a := &agent{name: name, tree: tree, board: board, events: map[node.IBevNode]*NodeView{}}
+	a.view = AgentView{Name: name, Frame: -1}
+	tree.AddListener(a)
+	a.debugger = debugger.Attach(tree, board)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.agents[name]; ok {
+		old.unregister()
+	}
+	s.agents[name] = a
+}
+
+// Unregister forgets the agent, its listener is removed by the goroutine updating the tree
+func (s *Server) Unregister(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.agents[name]; ok {
+		a.unregister()
+		delete(s.agents, name)
+	}
+}
+
+// sorted names of the registered agents
+func (s *Server) Agents() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.agents))
+	for name := range s.agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Debugger returns the debugger attached to the tree of the agent
+func (s *Server) Debugger(name string) (*debugger.Debugger, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.agents[name]
+	if !ok {
+		return nil, false
+	}
+	return a.debugger, true
+}
+
+// View returns the state captured at the end of the last frame of the agent
+func (s *Server) View(name string) (AgentView, bool) {
+	s.mu.RLock()
+	a, ok := s.agents[name]
+	s.mu.RUnlock()
+	if !ok {
+		return AgentView{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.view, true
+}
+
+/*
+ * agent listens to its tree, events are gathered during a frame and turned
+ * into a view when the root is ticked or transitioned by BevTree.Update
+ */
+type agent struct {
+	node.BevListener
+	name   string
+	tree   *node.BevTree
+	board  *bb.BlackBoard
+	events map[node.IBevNode]*NodeView
+
+	debugger *debugger.Debugger
+
+	mu           sync.Mutex
+	view         AgentView
+	unregistered bool
+}
+
+func (a *agent) unregister() {
+	a.debugger.Detach()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.unregistered = true
+}
+
+func (a *agent) event(n node.IBevNode) *NodeView {
+	v, ok := a.events[n]
+	if !ok {
+		v = &NodeView{}
+		a.events[n] = v
+	}
+	return v
+}
+
+func (a *agent) OnEvaluate(n node.IBevNode, result bool) {
+	v := a.event(n)
+	v.Evaluated, v.Result = true, result
+}
+
+func (a *agent) OnTick(n node.IBevNode, status node.BevRunningStatus) {
+	a.event(n).Status = status.String()
+	if n == a.tree.GetRoot() {
+		a.capture()
+	}
+}
+
+func (a *agent) OnTransition(n node.IBevNode) {
+	a.event(n).Status = node.BevRunningStatus(node.StateTransition).String()
+	if n == a.tree.GetRoot() {
+		a.capture()
+	}
+}
+
+func (a *agent) capture() {
+	view := AgentView{Name: a.name, Frame: a.tree.GetFrame(), ActivePath: []string{}}
+	active := map[node.IBevNode]bool{}
+	for _, n := range node.ActivePath(a.tree.GetRoot()) {
+		active[n] = true
+	}
+	scores := map[node.IBevNode]float64{}
+	node.Walk(a.tree.GetRoot(), func(n node.IBevNode, path string, depth int) {
+		v := NodeView{}
+		if e, ok := a.events[n]; ok {
+			v = *e
+		}
+		if score, ok := scores[n]; ok && !math.IsNaN(score) && !math.IsInf(score, 0) {
+			v.Score = &score
+		}
+		if childScores, ok := node.ChildScores(n); ok {
+			for child, score := range childScores {
+				scores[child] = score
+			}
+		}
+		v.Path, v.Label, v.Type, v.Depth = path, node.NodeLabel(n), node.NodeTypeName(n), depth
+		v.Active = active[n]
+		if v.Active {
+			view.ActivePath = append(view.ActivePath, path)
+		}
+		view.Nodes = append(view.Nodes, v)
+	})
+	a.events = map[node.IBevNode]*NodeView{}
+
+	if a.board != nil {
+		// values are encoded now, they may be changed while the view is served
+		view.Blackboard = map[string]json.RawMessage{}
+		a.board.Range(func(key int, value interface{}) bool {
+			data, err := json.Marshal(value)
+			if err != nil {
+				data, _ = json.Marshal(fmt.Sprintf("%T(%v)", value, value))
+			}
+			view.Blackboard[fmt.Sprint(key)] = data
+			return true
+		})
+	}
+
+	a.mu.Lock()
+	a.view = view
+	unregistered := a.unregistered
+	a.mu.Unlock()
+	if unregistered {
+		a.tree.RemoveListener(a)
+	}
+}
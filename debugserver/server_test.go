@@ -0,0 +1,142 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package debugserver
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	bt "github.com/ShionRyuu/gobevtree/builder"
+	"github.com/ShionRyuu/gobevtree/node"
+	p "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type forever struct {
+	*node.TerminalNode
+}
+
+func (this *forever) Enter(input interface{}) {
+}
+
+func (this *forever) Execute(input interface{}, output interface{}) node.BevRunningStatus {
+	return node.StateExecuting
+}
+
+func (this *forever) Exit(input interface{}, exitStatus node.BevRunningStatus) {
+}
+
+func get(server *Server, url string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+	return rec
+}
+
+func TestServer(t *testing.T) {
+	Convey("Registered agents are served as json and html", t, func() {
+		root, err := bt.Priority("root").
+			Child(bt.Terminal("flee", &forever{node.NewTerminalNode(nil, nil)}).When(p.NewPreconditionFALSE())).
+			Child(bt.Sequence("patrol").Child(bt.Terminal("walk", &forever{node.NewTerminalNode(nil, nil)}))).
+			Build()
+		So(err, ShouldBeNil)
+		tree := node.NewBevTree(root)
+		board := bb.NewBlackboard()
+		board.SetValueAsInt(1, 42)
+
+		server := NewServer()
+		server.Register("npc<1>", tree, board)
+		tree.Update(board, nil)
+
+		So(get(server, "/agents.json").Body.String(), ShouldEqual, "[\n  \"npc\\u003c1\\u003e\"\n]\n")
+
+		var view AgentView
+		rec := get(server, "/agent.json?name=npc%3C1%3E")
+		So(json.Unmarshal(rec.Body.Bytes(), &view), ShouldBeNil)
+		So(view.Frame, ShouldEqual, 0)
+		So(view.ActivePath, ShouldResemble, []string{"root", "root/patrol", "root/patrol/walk"})
+		So(view.Nodes[1], ShouldResemble, NodeView{Path: "root/flee", Label: "flee", Type: "forever", Depth: 1, Evaluated: true})
+		So(view.Nodes[3].Status, ShouldEqual, "executing")
+		So(string(view.Blackboard["1"]), ShouldEqual, "42")
+
+		html := get(server, "/agent?name=npc%3C1%3E").Body.String()
+		So(html, ShouldContainSubstring, "npc&lt;1&gt; frame 0")
+		So(html, ShouldContainSubstring, `<span class="active">        |— walk (forever) evaluate=true executing *</span>`)
+
+		So(get(server, "/agent?name=nobody").Code, ShouldEqual, 404)
+		server.Unregister("npc<1>")
+		So(server.Agents(), ShouldBeEmpty)
+	})
+}
+
+func post(server *Server, url string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("POST", url, nil))
+	return rec
+}
+
+func TestDebug(t *testing.T) {
+	Convey("Agents are paused and stepped through the debugger routes", t, func() {
+		root, err := bt.Sequence("root").
+			Child(bt.Terminal("walk", &forever{node.NewTerminalNode(nil, nil)})).
+			Build()
+		So(err, ShouldBeNil)
+		tree := node.NewBevTree(root)
+		board := bb.NewBlackboard()
+
+		server := NewServer()
+		server.Register("npc", tree, board)
+
+		So(post(server, "/break?name=npc&node=root/walk&on=executing").Code, ShouldEqual, 200)
+		So(post(server, "/break?name=npc&node=root/walk&on=later").Code, ShouldEqual, 400)
+		So(get(server, "/step?name=npc").Code, ShouldEqual, 405)
+		So(post(server, "/step?name=npc").Code, ShouldEqual, 409)
+
+		done := make(chan bool)
+		go func() {
+			tree.Update(board, nil)
+			done <- true
+		}()
+		d, _ := server.Debugger("npc")
+		_, ok := d.WaitPause(time.Second)
+		So(ok, ShouldBeTrue)
+
+		var view DebugView
+		So(json.Unmarshal(get(server, "/debug.json?name=npc").Body.Bytes(), &view), ShouldBeNil)
+		So(view.Paused, ShouldBeTrue)
+		So(view.Pause, ShouldEqual, "frame 0: tick root/walk returned executing (#1 root/walk returns executing)")
+		So(view.Breakpoints, ShouldResemble, []string{"#1 root/walk returns executing"})
+		So(view.Tree, ShouldContainSubstring, "walk")
+		So(get(server, "/agent?name=npc").Body.String(), ShouldContainSubstring, "paused at frame 0")
+
+		So(post(server, "/clear?name=npc").Code, ShouldEqual, 200)
+		So(post(server, "/continue?name=npc").Code, ShouldEqual, 200)
+		<-done
+		So(json.Unmarshal(get(server, "/debug.json?name=npc").Body.Bytes(), &view), ShouldBeNil)
+		So(view.Paused, ShouldBeFalse)
+		So(view.Breakpoints, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,93 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+)
+
+// json numbers become int when written without fraction nor exponent and
+// float64 otherwise, float64s of definitions built by code become int when integral
+func boardValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case float64:
+		if n == float64(int(n)) {
+			return int(n)
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return int(i)
+		}
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// SeedBlackboard fills board from a json object like {"1": 33, "2": "idle"},
+// keys can be names declared by the schema of board, see blackboard.Schema
+func SeedBlackboard(board *bb.BlackBoard, r io.Reader) error {
+	var values map[string]interface{}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		return err
+	}
+
+	for k, v := range values {
+		key, err := strconv.Atoi(k)
+		if err != nil {
+			if board.Schema() == nil {
+				return fmt.Errorf("blackboard key %q is not an integer", k)
+			}
+			err = board.SetByName(k, boardValue(v))
+		} else {
+			err = board.Set(key, boardValue(v))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func SeedBlackboardFile(board *bb.BlackBoard, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := SeedBlackboard(board, f); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
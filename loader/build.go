@@ -0,0 +1,170 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package loader
+
+import (
+	"fmt"
+
+	"github.com/ShionRyuu/gobevtree/node"
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+// Check reports every structural problem of a definition, prefixed with the node path
+func Check(def *NodeDef) []error {
+	var errs []error
+	if def == nil {
+		return append(errs, fmt.Errorf("empty tree"))
+	}
+
+	def.Walk(func(def *NodeDef, path string, depth int) {
+		report := func(format string, a ...interface{}) {
+			errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, a...)))
+		}
+
+		switch {
+		case IsComposite(def.Type):
+			if len(def.Children) == 0 {
+				report("%s has no children", def.Type)
+			}
+			if len(def.Children) > node.ConstMaxChildNodeCnt {
+				report("%d children, at most %d allowed", len(def.Children), node.ConstMaxChildNodeCnt)
+			}
+			if def.Type == "loop" && len(def.Children) > 1 {
+				report("loop only ticks its first child, got %d", len(def.Children))
+			}
+			for i, child := range def.Children {
+				if child == nil {
+					report("child %d is null", i)
+				}
+			}
+		case IsTerminal(def.Type):
+			if len(def.Children) > 0 {
+				report("terminal %s can not have children", def.Type)
+			}
+		default:
+			report("unknown node type %q", def.Type)
+		}
+
+		for _, err := range checkCond(def.Precondition) {
+			report("precondition: %v", err)
+		}
+	})
+	return errs
+}
+
+func checkCond(def *CondDef) []error {
+	var errs []error
+	if def == nil {
+		return errs
+	}
+	if _, ok := preconditions[def.Type]; !ok {
+		errs = append(errs, fmt.Errorf("unknown precondition type %q", def.Type))
+	}
+	if len(def.Args) < conditionArity[def.Type] {
+		errs = append(errs, fmt.Errorf("%s needs at least %d args, got %d", def.Type, conditionArity[def.Type], len(def.Args)))
+	}
+	for _, arg := range def.Args {
+		if arg == nil {
+			errs = append(errs, fmt.Errorf("%s has a null arg", def.Type))
+			continue
+		}
+		errs = append(errs, checkCond(arg)...)
+	}
+	return errs
+}
+
+// Build checks def and creates the tree, every node is wrapped so its precondition is evaluated
+func Build(def *NodeDef) (node.IBevNode, error) {
+	if errs := Check(def); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return build(def, def.Label(), nil)
+}
+
+func build(def *NodeDef, path string, parentNode node.IBevNode) (node.IBevNode, error) {
+	cond, err := BuildPrecondition(def.Precondition)
+	if err != nil {
+		return nil, fmt.Errorf("%s: precondition: %v", path, err)
+	}
+
+	var result node.IBevNode
+	if factory, ok := composites[def.Type]; ok {
+		composite, err := factory(def, parentNode)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		composite.SetNodePrecondition(cond)
+		selector := node.NewSelector(composite)
+		for i, childDef := range def.Children {
+			child, err := build(childDef, path+"/"+childDef.segment(i), selector)
+			if err != nil {
+				return nil, err
+			}
+			selector.AddChildNode(child)
+		}
+		result = selector
+	} else if factory, ok := terminals[def.Type]; ok {
+		terminal, err := factory(def, parentNode)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		terminal.SetNodePrecondition(cond)
+		result = node.NewTerminal(terminal)
+	} else {
+		return nil, fmt.Errorf("%s: unknown node type %q", path, def.Type)
+	}
+
+	result.SetDebugName(def.Name)
+	if def.Reverse {
+		result = node.NewReverse(result)
+	}
+	return result, nil
+}
+
+// BuildPrecondition creates the precondition of def, nil def gives nil
+func BuildPrecondition(def *CondDef) (p.IPrecondition, error) {
+	if def == nil {
+		return nil, nil
+	}
+	factory, ok := preconditions[def.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown precondition type %q", def.Type)
+	}
+	if len(def.Args) < conditionArity[def.Type] {
+		return nil, fmt.Errorf("%s needs at least %d args, got %d", def.Type, conditionArity[def.Type], len(def.Args))
+	}
+
+	args := make([]p.IPrecondition, 0, len(def.Args))
+	for _, argDef := range def.Args {
+		arg, err := BuildPrecondition(argDef)
+		if err != nil {
+			return nil, err
+		}
+		if arg == nil {
+			return nil, fmt.Errorf("%s has a null arg", def.Type)
+		}
+		args = append(args, arg)
+	}
+	return factory(def, args)
+}
@@ -0,0 +1,151 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+/*
+ * NodeDef describes one node of a tree definition file
+ */
+type NodeDef struct {
+	Type         string                 `json:"type"`
+	Name         string                 `json:"name,omitempty"`
+	Precondition *CondDef               `json:"precondition,omitempty"`
+	Reverse      bool                   `json:"reverse,omitempty"`
+	Params       map[string]interface{} `json:"params,omitempty"`
+	Children     []*NodeDef             `json:"children,omitempty"`
+}
+
+/*
+ * CondDef describes a precondition of a tree definition file
+ */
+type CondDef struct {
+	Type   string                 `json:"type"`
+	Args   []*CondDef             `json:"args,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// Parse reads a definition, numbers of params are kept as json.Number so
+// that 2.0 stays a float where a value or a bound is a float
+func Parse(r io.Reader) (*NodeDef, error) {
+	var def NodeDef
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(&def); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+func ParseFile(path string) (*NodeDef, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	def, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return def, nil
+}
+
+// label used in paths and diagnostics, name if set else type
+func (def *NodeDef) Label() string {
+	if def.Name != "" {
+		return def.Name
+	}
+	return def.Type
+}
+
+// path segment of the i-th child, unnamed children are told apart by index
+func (def *NodeDef) segment(i int) string {
+	if def.Name != "" {
+		return def.Name
+	}
+	return fmt.Sprintf("%s[%d]", def.Type, i)
+}
+
+// Walk visits def and all its non-nil descendants depth-first, path is slash separated
+func (def *NodeDef) Walk(visit func(def *NodeDef, path string, depth int)) {
+	def.walk(def.Label(), 0, visit)
+}
+
+func (def *NodeDef) walk(path string, depth int, visit func(def *NodeDef, path string, depth int)) {
+	visit(def, path, depth)
+	for i, child := range def.Children {
+		if child == nil {
+			continue
+		}
+		child.walk(path+"/"+child.segment(i), depth+1, visit)
+	}
+}
+
+/*
+ * Param helpers, json numbers are json.Number when parsed and float64 or
+ * int in definitions built by code
+ */
+func IntParam(params map[string]interface{}, name string, defValue int) (int, error) {
+	v, ok := params[name]
+	if !ok {
+		return defValue, nil
+	}
+	switch n := v.(type) {
+	case float64:
+		if n != float64(int(n)) {
+			return 0, fmt.Errorf("param %q: %v is not an integer", name, n)
+		}
+		return int(n), nil
+	case int:
+		return n, nil
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return int(i), nil
+		}
+		f, err := n.Float64()
+		if err != nil || f != float64(int(f)) {
+			return 0, fmt.Errorf("param %q: %v is not an integer", name, n)
+		}
+		return int(f), nil
+	}
+	return 0, fmt.Errorf("param %q: expect integer, got %T", name, v)
+}
+
+func StringParam(params map[string]interface{}, name string, defValue string) (string, error) {
+	v, ok := params[name]
+	if !ok {
+		return defValue, nil
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("param %q: expect string, got %T", name, v)
+}
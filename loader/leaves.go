@@ -0,0 +1,117 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package loader
+
+import (
+	"fmt"
+	"io"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	"github.com/ShionRyuu/gobevtree/node"
+)
+
+/*
+ * Builtin terminals, they print what they do when output is an io.Writer
+ */
+func trace(output interface{}, format string, a ...interface{}) {
+	if w, ok := output.(io.Writer); ok {
+		fmt.Fprintf(w, format+"\n", a...)
+	}
+}
+
+// finish at the first tick
+type ActionNode struct {
+	*node.TerminalNode
+	name string
+}
+
+func (this *ActionNode) Enter(input interface{}) {
+}
+
+func (this *ActionNode) Execute(input interface{}, output interface{}) node.BevRunningStatus {
+	trace(output, "  action %s", this.name)
+	return node.StateFinish
+}
+
+func (this *ActionNode) Exit(input interface{}, exitStatus node.BevRunningStatus) {
+}
+
+func (this *ActionNode) CloneNode() node.IBevNode {
+	return &ActionNode{node.NewTerminalNode(nil, nil), this.name}
+}
+
+// keep executing for the given frames
+type WaitNode struct {
+	*node.TerminalNode
+	name     string
+	waitTime int
+	useTime  int
+}
+
+func (this *WaitNode) Enter(input interface{}) {
+	this.useTime = 0
+}
+
+func (this *WaitNode) Execute(input interface{}, output interface{}) node.BevRunningStatus {
+	trace(output, "  wait %s %d/%d", this.name, this.useTime, this.waitTime)
+	if this.useTime >= this.waitTime {
+		return node.StateFinish
+	}
+	this.useTime += 1
+	return node.StateExecuting
+}
+
+func (this *WaitNode) Exit(input interface{}, exitStatus node.BevRunningStatus) {
+	this.useTime = 0
+}
+
+func (this *WaitNode) CloneNode() node.IBevNode {
+	return &WaitNode{node.NewTerminalNode(nil, nil), this.name, this.waitTime, this.useTime}
+}
+
+// write a value into the input blackboard
+type SetNode struct {
+	*node.TerminalNode
+	name  string
+	key   int
+	value interface{}
+}
+
+func (this *SetNode) Enter(input interface{}) {
+}
+
+func (this *SetNode) Execute(input interface{}, output interface{}) node.BevRunningStatus {
+	if board, ok := input.(*bb.BlackBoard); ok {
+		board.SetValueAsInterface(this.key, this.value)
+		trace(output, "  set %s [%d]=%v", this.name, this.key, this.value)
+	}
+	return node.StateFinish
+}
+
+func (this *SetNode) Exit(input interface{}, exitStatus node.BevRunningStatus) {
+}
+
+func (this *SetNode) CloneNode() node.IBevNode {
+	return &SetNode{node.NewTerminalNode(nil, nil), this.name, this.key, this.value}
+}
@@ -0,0 +1,191 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	p "github.com/ShionRyuu/gobevtree/precondition"
+	"github.com/ShionRyuu/gobevtree/precondition/expr"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const simpleTree = `{
+	"type": "priority", "name": "root",
+	"children": [
+		{"type": "sequence", "name": "seq",
+		 "precondition": {"type": "less", "params": {"first": 1, "second": 2}},
+		 "children": [
+			{"type": "action", "name": "a"},
+			{"type": "set", "name": "s", "params": {"key": 1, "value": 20}}
+		 ]},
+		{"type": "action", "name": "idle"}
+	]
+}`
+
+func TestBuild(t *testing.T) {
+	Convey("A valid definition builds a runnable tree", t, func() {
+		def, err := Parse(strings.NewReader(simpleTree))
+		So(err, ShouldBeNil)
+		So(Check(def), ShouldBeEmpty)
+
+		tree, err := Build(def)
+		So(err, ShouldBeNil)
+		So(tree.GetDebugName(), ShouldEqual, "root")
+
+		board := bb.NewBlackboard()
+		So(SeedBlackboard(board, strings.NewReader(`{"1": 0, "2": 10}`)), ShouldBeNil)
+
+		var out bytes.Buffer
+		for i := 0; i < 3; i++ {
+			if tree.Evaluate(board) {
+				tree.Tick(board, &out)
+			}
+		}
+		So(out.String(), ShouldEqual, "  action a\n  set s [1]=20\n  action idle\n")
+	})
+
+	Convey("Problems are reported with node paths", t, func() {
+		def, err := Parse(strings.NewReader(`{"type": "priority", "name": "root", "children": [
+			{"type": "sequence"},
+			{"type": "bogus"},
+			{"type": "action", "precondition": {"type": "and", "args": [{"type": "true"}]}}
+		]}`))
+		So(err, ShouldBeNil)
+
+		errs := Check(def)
+		So(len(errs), ShouldEqual, 3)
+		So(errs[0].Error(), ShouldEqual, "root/sequence[0]: sequence has no children")
+		So(errs[1].Error(), ShouldEqual, `root/bogus[1]: unknown node type "bogus"`)
+		So(errs[2].Error(), ShouldStartWith, "root/action[2]: precondition:")
+
+		_, err = Build(def)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Combinators are built from definitions", t, func() {
+		def := &CondDef{Type: "atleast", Params: map[string]interface{}{"k": 2}, Args: []*CondDef{
+			{Type: "true"},
+			{Type: "not", Args: []*CondDef{{Type: "false"}}},
+			{Type: "xor", Args: []*CondDef{{Type: "true"}, {Type: "true"}}},
+		}}
+		cond, err := BuildPrecondition(def)
+		So(err, ShouldBeNil)
+		So(p.Describe(cond), ShouldEqual, "atLeast(2, true, not(false), xor(true, true))")
+		So(cond.ExternalCondition(nil), ShouldBeTrue)
+	})
+
+	Convey("Seeds are validated by the schema of the board", t, func() {
+		board := bb.NewBlackboard().SetSchema(bb.NewSchema().
+			MustDeclare(bb.KeySpec{Key: 1, Name: "hp", Type: bb.TypeInt}).
+			MustDeclare(bb.KeySpec{Key: 2, Name: "speed", Type: bb.TypeFloat64}))
+		So(SeedBlackboard(board, strings.NewReader(`{"hp": 3, "2": 10}`)), ShouldBeNil)
+		speed, err := board.GetValueAsFloat64(2)
+		So(speed, ShouldEqual, 10)
+		So(err, ShouldBeNil)
+		So(SeedBlackboard(board, strings.NewReader(`{"hp": "idle"}`)), ShouldNotBeNil)
+		So(SeedBlackboard(bb.NewBlackboard(), strings.NewReader(`{"hp": 3}`)), ShouldNotBeNil)
+	})
+
+	Convey("Float literals build float comparisons", t, func() {
+		board := bb.NewBlackboard()
+		board.SetValueAsFloat64(1, 3.5)
+		for text, want := range map[string]string{
+			`{"type": "compare", "params": {"key": 1, "op": ">=", "value": 2.0}}`: "$1 >= 2",
+			`{"type": "inrange", "params": {"key": 1, "min": 0.0, "max": 5.0}}`:   "$1 in [0, 5]",
+		} {
+			cond, err := BuildPrecondition(parseCond(text))
+			So(err, ShouldBeNil)
+			So(p.Describe(cond), ShouldEqual, want)
+			So(cond.ExternalCondition(board), ShouldBeTrue)
+		}
+
+		def, err := Parse(strings.NewReader(`{"type": "set", "params": {"key": 2.0, "value": 1.0}}`))
+		So(err, ShouldBeNil)
+		tree, err := Build(def)
+		So(err, ShouldBeNil)
+		tree.Tick(board, nil)
+		value, err := board.GetValueAsFloat64(2)
+		So(value, ShouldEqual, 1)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Blackboard comparisons are built from definitions", t, func() {
+		board := bb.NewBlackboard()
+		So(SeedBlackboard(board, strings.NewReader(`{"1": 3, "2": 10, "3": "idle"}`)), ShouldBeNil)
+		for text, want := range map[string]string{
+			`{"type": "less", "params": {"first": 1, "second": 2}}`:                               "$1 < $2",
+			`{"type": "compare", "params": {"key": 1, "op": ">=", "other": 2}}`:                   "$1 >= $2",
+			`{"type": "compare", "params": {"key": 3, "value": "idle"}}`:                          `$3 == "idle"`,
+			`{"type": "compare", "params": {"key": 9, "op": "<", "value": 1, "missing": "true"}}`: "$9 < 1",
+			`{"type": "inrange", "params": {"key": 1, "min": 0, "max": 5}}`:                       "$1 in [0, 5]",
+			`{"type": "exists", "params": {"key": 3}}`:                                            "exists($3)",
+		} {
+			cond, err := BuildPrecondition(parseCond(text))
+			So(err, ShouldBeNil)
+			So(p.Describe(cond), ShouldEqual, want)
+			So(cond.ExternalCondition(board), ShouldEqual, want != "$1 >= $2")
+		}
+
+		_, err := BuildPrecondition(&CondDef{Type: "compare", Params: map[string]interface{}{"key": 1, "op": "=~", "value": 1}})
+		So(err, ShouldNotBeNil)
+
+		cond, err := BuildPrecondition(&CondDef{Type: "compare", Params: map[string]interface{}{"key": 9, "value": 1, "missing": "error"}})
+		So(err, ShouldBeNil)
+		_, err = p.Check(cond, board)
+		So(err.Error(), ShouldEqual, "precondition $9 == 1: key 9: Invalid Key")
+	})
+
+	Convey("Expressions are compiled with the env set by SetExprEnv", t, func() {
+		SetExprEnv(expr.NewEnv().Declare("hp", 1, expr.Int))
+		defer SetExprEnv(expr.NewEnv())
+
+		cond, err := BuildPrecondition(&CondDef{Type: "expr", Params: map[string]interface{}{"expr": "hp < 30"}})
+		So(err, ShouldBeNil)
+		So(p.Describe(cond), ShouldEqual, "hp < 30")
+
+		_, err = BuildPrecondition(&CondDef{Type: "expr", Params: map[string]interface{}{"expr": "ammo > 0"}})
+		So(err.Error(), ShouldEqual, `column 1: unknown name "ammo"`)
+	})
+
+	Convey("Unknown fields are rejected", t, func() {
+		_, err := Parse(strings.NewReader(`{"type": "action", "child": []}`))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+// decode a precondition like Parse does
+func parseCond(text string) *CondDef {
+	var def CondDef
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	if err := dec.Decode(&def); err != nil {
+		panic(err)
+	}
+	return &def
+}
@@ -0,0 +1,318 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package loader
+
+import (
+	"fmt"
+
+	"github.com/ShionRyuu/gobevtree/node"
+	p "github.com/ShionRyuu/gobevtree/precondition"
+	"github.com/ShionRyuu/gobevtree/precondition/expr"
+)
+
+/*
+ * Factories used to turn definitions into nodes, keyed by the "type" field.
+ * Composites are wrapped by NewSelector and terminals by NewTerminal when built,
+ * the precondition and debug name of the definition are set by the loader.
+ */
+type CompositeFactory func(def *NodeDef, parentNode node.IBevNode) (node.IBevSelector, error)
+type TerminalFactory func(def *NodeDef, parentNode node.IBevNode) (node.IBevTerminal, error)
+type PreconditionFactory func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error)
+
+var (
+	composites    = map[string]CompositeFactory{}
+	terminals     = map[string]TerminalFactory{}
+	preconditions = map[string]PreconditionFactory{}
+	exprEnv       = expr.NewEnv()
+)
+
+func RegisterComposite(typ string, factory CompositeFactory) {
+	composites[typ] = factory
+}
+
+func RegisterTerminal(typ string, factory TerminalFactory) {
+	terminals[typ] = factory
+}
+
+func RegisterPrecondition(typ string, factory PreconditionFactory) {
+	preconditions[typ] = factory
+}
+
+// SetExprEnv sets the names usable by the "expr" preconditions of definitions
+func SetExprEnv(env *expr.Env) {
+	exprEnv = env
+}
+
+func IsComposite(typ string) bool {
+	_, ok := composites[typ]
+	return ok
+}
+
+func IsTerminal(typ string) bool {
+	_, ok := terminals[typ]
+	return ok
+}
+
+func init() {
+	RegisterComposite("priority", func(def *NodeDef, parentNode node.IBevNode) (node.IBevSelector, error) {
+		return node.NewPrioritySelector(parentNode, nil), nil
+	})
+	RegisterComposite("nonepriority", func(def *NodeDef, parentNode node.IBevNode) (node.IBevSelector, error) {
+		return node.NewNonePrioritySelector(parentNode, nil), nil
+	})
+	RegisterComposite("sequence", func(def *NodeDef, parentNode node.IBevNode) (node.IBevSelector, error) {
+		return node.NewSequenceSelector(parentNode, nil), nil
+	})
+	RegisterComposite("parallel", func(def *NodeDef, parentNode node.IBevNode) (node.IBevSelector, error) {
+		return node.NewParallelSelector(parentNode, nil), nil
+	})
+	RegisterComposite("random", func(def *NodeDef, parentNode node.IBevNode) (node.IBevSelector, error) {
+		random := node.NewRandomSelector(parentNode, nil)
+		if _, ok := def.Params["seed"]; ok {
+			seed, err := IntParam(def.Params, "seed", 0)
+			if err != nil {
+				return nil, err
+			}
+			random.SetSeed(int64(seed))
+		}
+		return random, nil
+	})
+	RegisterComposite("loop", func(def *NodeDef, parentNode node.IBevNode) (node.IBevSelector, error) {
+		count, err := IntParam(def.Params, "count", node.ConstInfiniteLoop)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 && count != node.ConstInfiniteLoop {
+			return nil, fmt.Errorf("param \"count\": invalid loop count %d", count)
+		}
+		return node.NewLoopSelector(parentNode, nil, count), nil
+	})
+
+	RegisterTerminal("action", func(def *NodeDef, parentNode node.IBevNode) (node.IBevTerminal, error) {
+		return &ActionNode{node.NewTerminalNode(parentNode, nil), def.Label()}, nil
+	})
+	RegisterTerminal("wait", func(def *NodeDef, parentNode node.IBevNode) (node.IBevTerminal, error) {
+		frames, err := IntParam(def.Params, "frames", 1)
+		if err != nil {
+			return nil, err
+		}
+		return &WaitNode{node.NewTerminalNode(parentNode, nil), def.Label(), frames, 0}, nil
+	})
+	RegisterTerminal("set", func(def *NodeDef, parentNode node.IBevNode) (node.IBevTerminal, error) {
+		key, err := IntParam(def.Params, "key", 0)
+		if err != nil {
+			return nil, err
+		}
+		value, ok := def.Params["value"]
+		if !ok {
+			return nil, fmt.Errorf("param \"value\" is required")
+		}
+		return &SetNode{node.NewTerminalNode(parentNode, nil), def.Label(), key, boardValue(value)}, nil
+	})
+
+	RegisterPrecondition("true", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		return p.NewPreconditionTRUE(), nil
+	})
+	RegisterPrecondition("false", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		return p.NewPreconditionFALSE(), nil
+	})
+	RegisterPrecondition("and", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		cond := args[0]
+		for _, arg := range args[1:] {
+			cond = p.NewPreconditionAND(cond, arg)
+		}
+		return cond, nil
+	})
+	RegisterPrecondition("or", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		cond := args[0]
+		for _, arg := range args[1:] {
+			cond = p.NewPreconditionOR(cond, arg)
+		}
+		return cond, nil
+	})
+	RegisterPrecondition("not", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		return p.Not(args[0]), nil
+	})
+	RegisterPrecondition("xor", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		return p.Xor(args[0], args[1]), nil
+	})
+	RegisterPrecondition("all", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		return p.All(args...), nil
+	})
+	RegisterPrecondition("any", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		return p.Any(args...), nil
+	})
+	RegisterPrecondition("none", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		return p.None(args...), nil
+	})
+	RegisterPrecondition("atleast", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		k, err := IntParam(def.Params, "k", 1)
+		if err != nil {
+			return nil, err
+		}
+		return p.AtLeast(k, args...), nil
+	})
+	RegisterPrecondition("less", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		first, err := IntParam(def.Params, "first", 0)
+		if err != nil {
+			return nil, err
+		}
+		second, err := IntParam(def.Params, "second", 0)
+		if err != nil {
+			return nil, err
+		}
+		return p.CompareIntKeys(first, p.OpLess, second), nil
+	})
+	RegisterPrecondition("compare", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		key, err := IntParam(def.Params, "key", 0)
+		if err != nil {
+			return nil, err
+		}
+		opName, err := StringParam(def.Params, "op", "==")
+		if err != nil {
+			return nil, err
+		}
+		op, err := p.ParseOperator(opName)
+		if err != nil {
+			return nil, err
+		}
+
+		var cond *p.PreconditionBoard
+		if _, ok := def.Params["other"]; ok {
+			other, err := IntParam(def.Params, "other", 0)
+			if err != nil {
+				return nil, err
+			}
+			kind, err := StringParam(def.Params, "kind", "int")
+			if err != nil {
+				return nil, err
+			}
+			switch kind {
+			case "int":
+				cond = p.CompareIntKeys(key, op, other)
+			case "float":
+				cond = p.CompareFloatKeys(key, op, other)
+			case "string":
+				cond = p.CompareStringKeys(key, op, other)
+			case "bool":
+				cond = p.CompareBoolKeys(key, op, other)
+			default:
+				return nil, fmt.Errorf("param \"kind\": unknown kind %q", kind)
+			}
+		} else {
+			switch value := boardValue(def.Params["value"]).(type) {
+			case int:
+				cond = p.CompareInt(key, op, value)
+			case float64:
+				cond = p.CompareFloat(key, op, value)
+			case string:
+				cond = p.CompareString(key, op, value)
+			case bool:
+				cond = p.CompareBool(key, op, value)
+			default:
+				return nil, fmt.Errorf("param \"value\" or \"other\" is required")
+			}
+		}
+		return keyPolicies(def, cond)
+	})
+	RegisterPrecondition("inrange", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		key, err := IntParam(def.Params, "key", 0)
+		if err != nil {
+			return nil, err
+		}
+		min, okMin := boardValue(def.Params["min"]).(int)
+		max, okMax := boardValue(def.Params["max"]).(int)
+		if okMin && okMax {
+			return keyPolicies(def, p.InRangeInt(key, min, max))
+		}
+		fmin, okMin := floatParam(def.Params["min"])
+		fmax, okMax := floatParam(def.Params["max"])
+		if !okMin || !okMax {
+			return nil, fmt.Errorf("params \"min\" and \"max\" must be numbers")
+		}
+		return keyPolicies(def, p.InRangeFloat(key, fmin, fmax))
+	})
+	RegisterPrecondition("expr", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		src, err := StringParam(def.Params, "expr", "")
+		if err != nil {
+			return nil, err
+		}
+		return expr.Compile(src, exprEnv)
+	})
+	RegisterPrecondition("exists", func(def *CondDef, args []p.IPrecondition) (p.IPrecondition, error) {
+		key, err := IntParam(def.Params, "key", 0)
+		if err != nil {
+			return nil, err
+		}
+		return p.KeyExists(key), nil
+	})
+}
+
+var keyPolicyNames = map[string]p.KeyPolicy{
+	"false": p.PolicyFalse,
+	"true":  p.PolicyTrue,
+	"panic": p.PolicyPanic,
+	"error": p.PolicyError,
+}
+
+// apply the optional "missing" and "wrongtype" params, "false", "true", "panic" or "error"
+func keyPolicies(def *CondDef, cond *p.PreconditionBoard) (p.IPrecondition, error) {
+	for name, set := range map[string]func(p.KeyPolicy) *p.PreconditionBoard{
+		"missing":   cond.OnMissing,
+		"wrongtype": cond.OnWrongType,
+	} {
+		s, err := StringParam(def.Params, name, "false")
+		if err != nil {
+			return nil, err
+		}
+		policy, ok := keyPolicyNames[s]
+		if !ok {
+			return nil, fmt.Errorf("param %q: unknown policy %q", name, s)
+		}
+		set(policy)
+	}
+	return cond, nil
+}
+
+func floatParam(v interface{}) (float64, bool) {
+	switch n := boardValue(v).(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// minimal number of args of the builtin preconditions
+var conditionArity = map[string]int{
+	"and":     2,
+	"or":      2,
+	"not":     1,
+	"xor":     2,
+	"all":     1,
+	"any":     1,
+	"none":    1,
+	"atleast": 1,
+}
@@ -0,0 +1,98 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Publish exposes the snapshot under name in expvar, it panics if name is already published
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.Snapshot()
+	}))
+}
+
+type metricDesc struct {
+	name  string
+	kind  string
+	help  string
+	value func(m *NodeMetrics) float64
+}
+
+var metricDescs = []metricDesc{
+	{"gobevtree_node_evaluations_total", "counter", "Number of evaluations of the node.",
+		func(m *NodeMetrics) float64 { return float64(m.Evaluations) }},
+	{"gobevtree_node_evaluate_failures_total", "counter", "Number of evaluations of the node which returned false.",
+		func(m *NodeMetrics) float64 { return float64(m.EvaluateFailures) }},
+	{"gobevtree_node_ticks_total", "counter", "Number of ticks of the node.",
+		func(m *NodeMetrics) float64 { return float64(m.Ticks) }},
+	{"gobevtree_node_finishes_total", "counter", "Number of ticks of the node which returned finish.",
+		func(m *NodeMetrics) float64 { return float64(m.Finishes) }},
+	{"gobevtree_node_interrupts_total", "counter", "Number of times the terminal exited by a transition.",
+		func(m *NodeMetrics) float64 { return float64(m.Interrupts) }},
+	{"gobevtree_node_executes_total", "counter", "Number of Execute calls of the terminal.",
+		func(m *NodeMetrics) float64 { return float64(m.Executes) }},
+	{"gobevtree_node_execute_seconds_total", "counter", "Time spent in Execute of the terminal.",
+		func(m *NodeMetrics) float64 { return m.ExecuteTime.Seconds() }},
+	{"gobevtree_node_runs_total", "counter", "Number of times the terminal ran from enter to exit.",
+		func(m *NodeMetrics) float64 { return float64(m.Runs) }},
+	{"gobevtree_node_running_frames_total", "counter", "Frames the terminal spent running.",
+		func(m *NodeMetrics) float64 { return float64(m.RunningFrames) }},
+	{"gobevtree_node_running_seconds_total", "counter", "Time the terminal spent running.",
+		func(m *NodeMetrics) float64 { return m.RunningTime.Seconds() }},
+	{"gobevtree_node_running_seconds_max", "gauge", "Longest time the terminal kept running.",
+		func(m *NodeMetrics) float64 { return m.MaxRunningTime.Seconds() }},
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the snapshot in the prometheus text exposition format
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	snapshot := c.Snapshot()
+	for _, desc := range metricDescs {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", desc.name, desc.help, desc.name, desc.kind); err != nil {
+			return err
+		}
+		for i := range snapshot {
+			m := &snapshot[i]
+			_, err := fmt.Fprintf(w, "%s{tree=\"%s\",node=\"%s\"} %g\n",
+				desc.name, labelEscaper.Replace(m.Tree), labelEscaper.Replace(m.Path), desc.value(m))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the prometheus text format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WritePrometheus(w)
+}
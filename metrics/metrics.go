@@ -0,0 +1,205 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+/*
+ * Package metrics counts what the nodes of trees do, and exposes the counts
+ * through expvar and the prometheus text format. Nodes are identified by the
+ * name of their tree and their path, made of debug names.
+ */
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ShionRyuu/gobevtree/node"
+)
+
+// NodeMetrics of one node, Finishes are successful ticks and EvaluateFailures failed evaluations
+type NodeMetrics struct {
+	Tree             string
+	Path             string
+	Evaluations      uint64
+	EvaluateFailures uint64
+	Ticks            uint64
+	Finishes         uint64
+	Interrupts       uint64
+	Executes         uint64
+	ExecuteTime      time.Duration
+	Runs             uint64
+	RunningFrames    uint64
+	RunningTime      time.Duration
+	MaxRunningTime   time.Duration
+}
+
+/*
+ * Collector gathers the metrics of the trees it observes, trees may be updated
+ * from different goroutines
+ */
+type Collector struct {
+	mu    sync.Mutex
+	trees []*treeMetrics
+}
+
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Observe attaches a listener to tree, name tells trees apart in the metrics
+func (c *Collector) Observe(name string, tree *node.BevTree) {
+	t := &treeMetrics{
+		name:    name,
+		tree:    tree,
+		paths:   node.Paths(tree.GetRoot()),
+		nodes:   map[string]*NodeMetrics{},
+		running: map[node.IBevNode]run{},
+		now:     time.Now,
+	}
+	tree.AddListener(t)
+
+	c.mu.Lock()
+	c.trees = append(c.trees, t)
+	c.mu.Unlock()
+}
+
+// Snapshot returns a copy of the metrics sorted by tree and path
+func (c *Collector) Snapshot() []NodeMetrics {
+	c.mu.Lock()
+	trees := append([]*treeMetrics(nil), c.trees...)
+	c.mu.Unlock()
+
+	var result []NodeMetrics
+	for _, t := range trees {
+		t.mu.Lock()
+		for _, m := range t.nodes {
+			result = append(result, *m)
+		}
+		t.mu.Unlock()
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Tree != result[j].Tree {
+			return result[i].Tree < result[j].Tree
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// Reset clears the counts, terminals which are running are still measured
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range c.trees {
+		t.mu.Lock()
+		t.nodes = map[string]*NodeMetrics{}
+		t.mu.Unlock()
+	}
+}
+
+type run struct {
+	start time.Time
+	frame int
+}
+
+// treeMetrics is the listener of one tree
+type treeMetrics struct {
+	node.BevListener
+	mu      sync.Mutex
+	name    string
+	tree    *node.BevTree
+	paths   map[node.IBevNode]string
+	nodes   map[string]*NodeMetrics
+	running map[node.IBevNode]run
+	now     func() time.Time
+}
+
+// must be called with mu held
+func (t *treeMetrics) get(n node.IBevNode) *NodeMetrics {
+	path, ok := t.paths[n]
+	if !ok {
+		t.paths = node.Paths(t.tree.GetRoot())
+		if path, ok = t.paths[n]; !ok {
+			path = node.NodeLabel(n)
+		}
+	}
+	m, ok := t.nodes[path]
+	if !ok {
+		m = &NodeMetrics{Tree: t.name, Path: path}
+		t.nodes[path] = m
+	}
+	return m
+}
+
+func (t *treeMetrics) OnEvaluate(n node.IBevNode, result bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.get(n)
+	m.Evaluations++
+	if !result {
+		m.EvaluateFailures++
+	}
+}
+
+func (t *treeMetrics) OnEnter(n node.IBevNode) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.running[n] = run{t.now(), t.tree.GetFrame()}
+}
+
+func (t *treeMetrics) OnTick(n node.IBevNode, status node.BevRunningStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.get(n)
+	m.Ticks++
+	if status == node.StateFinish {
+		m.Finishes++
+	}
+}
+
+func (t *treeMetrics) OnExit(n node.IBevNode, status node.BevRunningStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.get(n)
+	if status == node.StateTransition {
+		m.Interrupts++
+	}
+	if r, ok := t.running[n]; ok {
+		delete(t.running, n)
+		elapsed := t.now().Sub(r.start)
+		m.Runs++
+		m.RunningFrames += uint64(t.tree.GetFrame() - r.frame + 1)
+		m.RunningTime += elapsed
+		if elapsed > m.MaxRunningTime {
+			m.MaxRunningTime = elapsed
+		}
+	}
+}
+
+func (t *treeMetrics) OnExecute(n node.IBevNode, status node.BevRunningStatus, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.get(n)
+	m.Executes++
+	m.ExecuteTime += elapsed
+}
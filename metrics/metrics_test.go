@@ -0,0 +1,114 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package metrics
+
+import (
+	"expvar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bt "github.com/ShionRyuu/gobevtree/builder"
+	"github.com/ShionRyuu/gobevtree/node"
+	p "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// run for two frames
+type wait struct {
+	*node.TerminalNode
+	frames int
+}
+
+func (this *wait) Enter(input interface{}) {
+	this.frames = 0
+}
+
+func (this *wait) Execute(input interface{}, output interface{}) node.BevRunningStatus {
+	this.frames++
+	if this.frames < 2 {
+		return node.StateExecuting
+	}
+	return node.StateFinish
+}
+
+func (this *wait) Exit(input interface{}, exitStatus node.BevRunningStatus) {
+}
+
+func TestCollector(t *testing.T) {
+	Convey("Counts are kept per node path", t, func() {
+		root, err := bt.Priority("root").
+			Child(bt.Terminal("never", &wait{node.NewTerminalNode(nil, nil), 0}).When(p.NewPreconditionFALSE())).
+			Child(bt.Terminal("wait", &wait{node.NewTerminalNode(nil, nil), 0})).
+			Build()
+		So(err, ShouldBeNil)
+		tree := node.NewBevTree(root)
+
+		collector := NewCollector()
+		collector.Observe("npc", tree)
+		clock := time.Unix(0, 0)
+		collector.trees[0].now = func() time.Time {
+			clock = clock.Add(time.Second)
+			return clock
+		}
+		for i := 0; i < 4; i++ {
+			tree.Update(nil, nil)
+		}
+
+		snapshot := collector.Snapshot()
+		So(len(snapshot), ShouldEqual, 3)
+		So(snapshot[0].Path, ShouldEqual, "root")
+		So(snapshot[1].Path, ShouldEqual, "root/never")
+		So(snapshot[1].Evaluations, ShouldEqual, 4)
+		So(snapshot[1].EvaluateFailures, ShouldEqual, 4)
+
+		waitMetrics := snapshot[2]
+		So(waitMetrics.Ticks, ShouldEqual, 4)
+		So(waitMetrics.Finishes, ShouldEqual, 2)
+		So(waitMetrics.Executes, ShouldEqual, 4)
+		So(waitMetrics.Runs, ShouldEqual, 2)
+		So(waitMetrics.RunningFrames, ShouldEqual, 4)
+		So(waitMetrics.RunningTime, ShouldEqual, 2*time.Second)
+		So(waitMetrics.MaxRunningTime, ShouldEqual, time.Second)
+
+		Convey("They are served in the prometheus text format", func() {
+			rec := httptest.NewRecorder()
+			collector.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			body := rec.Body.String()
+			So(body, ShouldContainSubstring, "# TYPE gobevtree_node_ticks_total counter\n")
+			So(body, ShouldContainSubstring, "gobevtree_node_ticks_total{tree=\"npc\",node=\"root/wait\"} 4\n")
+			So(body, ShouldContainSubstring, "gobevtree_node_running_seconds_max{tree=\"npc\",node=\"root/wait\"} 1\n")
+		})
+
+		Convey("And through expvar", func() {
+			collector.Publish("gobevtree_test")
+			So(expvar.Get("gobevtree_test").String(), ShouldContainSubstring, `"Path":"root/wait"`)
+		})
+
+		Convey("Reset clears the counts", func() {
+			collector.Reset()
+			So(collector.Snapshot(), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,98 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+// whether node is a terminal between its Enter and its Exit
+func isRunning(node IBevNode) bool {
+	for {
+		switch w := node.(type) {
+		case *BevTerminal:
+			return w.needExit
+		case *BevSelector:
+			node = w.IBevSelector
+		case *BevReverse:
+			node = w.IBevNode
+		default:
+			return false
+		}
+	}
+}
+
+/*
+ * ActivePath returns the chain from root to the running terminal, nodes as
+ * added to their parents, or nil when no terminal is running. The running
+ * terminals are found from the root down so it does not depend on parent
+ * nodes being set. When several run, which custom composites may do, the one
+ * last ticked is preferred, then the first one in depth-first order.
+ */
+func ActivePath(root IBevNode) []IBevNode {
+	if root == nil {
+		return nil
+	}
+	var last *BevNode
+	if active := bevNodeOf(root).GetActiveNode(); active != nil {
+		last = bevNodeOf(active)
+	}
+
+	var first, preferred []IBevNode
+	var find func(node IBevNode, path []IBevNode, visited map[*BevNode]bool)
+	find = func(node IBevNode, path []IBevNode, visited map[*BevNode]bool) {
+		base := bevNodeOf(node)
+		if visited[base] || preferred != nil {
+			return
+		}
+		visited[base] = true
+		path = append(path, node)
+		if isRunning(node) {
+			found := append([]IBevNode(nil), path...)
+			if first == nil {
+				first = found
+			}
+			if base == last {
+				preferred = found
+			}
+			return
+		}
+		for _, child := range base.getChildNodes() {
+			if child != nil {
+				find(child, path, visited)
+			}
+		}
+	}
+	find(root, nil, map[*BevNode]bool{})
+
+	if preferred != nil {
+		return preferred
+	}
+	return first
+}
+
+// ActiveLeaf returns the running terminal of ActivePath, nil when none runs
+func ActiveLeaf(root IBevNode) IBevNode {
+	path := ActivePath(root)
+	if len(path) == 0 {
+		return nil
+	}
+	return path[len(path)-1]
+}
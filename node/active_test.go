@@ -0,0 +1,83 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func labels(nodes []IBevNode) []string {
+	var names []string
+	for _, n := range nodes {
+		names = append(names, NodeLabel(n))
+	}
+	return names
+}
+
+func TestActivePath(t *testing.T) {
+	Convey("Active path of a tree wired without parent nodes", t, func() {
+		root := NewSelector(NewSequenceSelector(nil, nil))
+		root.SetDebugName("root")
+		branch := NewSelector(NewPrioritySelector(nil, nil))
+		branch.SetDebugName("branch")
+		walk := NewTerminal(&B{NewTerminalNode(nil, nil), 0})
+		walk.SetDebugName("walk")
+		root.AddChildNode(branch)
+		branch.AddChildNode(walk)
+		tree := NewBevTree(root)
+
+		So(ActivePath(root), ShouldBeNil)
+		So(ActiveLeaf(nil), ShouldBeNil)
+
+		output := 0
+		tree.Update(nil, &output)
+		So(labels(ActivePath(root)), ShouldResemble, []string{"root", "branch", "walk"})
+		So(ActiveLeaf(root), ShouldEqual, walk)
+
+		tree.Update(nil, &output)
+		So(ActiveLeaf(root), ShouldBeNil)
+	})
+
+	Convey("Active path follows the branch chosen by a priority selector", t, func() {
+		fight := true
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.SetDebugName("root")
+		attack := NewTerminal(&B{NewTerminalNode(root, flagCond{&fight}), 0})
+		attack.SetDebugName("attack")
+		idle := NewTerminal(&B{NewTerminalNode(root, nil), 0})
+		idle.SetDebugName("idle")
+		root.AddChildNode(attack)
+		root.AddChildNode(idle)
+		tree := NewBevTree(root)
+
+		output := 0
+		tree.Update(nil, &output)
+		So(labels(ActivePath(root)), ShouldResemble, []string{"root", "attack"})
+		fight = false
+		tree.Update(nil, &output)
+		So(labels(ActivePath(root)), ShouldResemble, []string{"root", "idle"})
+	})
+}
@@ -0,0 +1,160 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"fmt"
+	"reflect"
+
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+/*
+ * Cloner is implemented by terminals and composites which can be copied by Clone.
+ * CloneNode returns a new node of the same type holding a copy of the fields of
+ * the type, eg. &TestTerNode{NewTerminalNode(nil, nil), this.data}, Clone fills in
+ * the precondition, debug name, parent, children and active nodes.
+ */
+type Cloner interface {
+	CloneNode() IBevNode
+}
+
+/*
+ * Clone deep copies root with its wrappers, children and run state, the copy has no parent.
+ * Preconditions are shared by both trees unless they implement p.IPreconditionCloner,
+ * a precondition shared by several nodes is still shared by their copies. Cached
+ * ones, see p.Cache.Wrap, get a cache of their own, reached by their Cache method.
+ */
+func Clone(root IBevNode) (IBevNode, error) {
+	c := &treeCloner{
+		nodes: map[IBevNode]IBevNode{},
+		conds: map[p.IPrecondition]p.IPrecondition{},
+		bases: map[*BevNode]*BevNode{},
+	}
+	clone, err := c.clone(root, nil, NodeLabel(root))
+	if err != nil {
+		return nil, err
+	}
+
+	for old, clone := range c.bases {
+		clone.activeNode = c.nodes[old.activeNode]
+		clone.lastActiveNode = c.nodes[old.lastActiveNode]
+	}
+	return clone, nil
+}
+
+type treeCloner struct {
+	nodes map[IBevNode]IBevNode
+	conds map[p.IPrecondition]p.IPrecondition
+	bases map[*BevNode]*BevNode
+}
+
+func (c *treeCloner) clone(node IBevNode, parentNode IBevNode, path string) (IBevNode, error) {
+	if node == nil {
+		return nil, nil
+	}
+	if clone, ok := c.nodes[node]; ok {
+		return clone, nil
+	}
+
+	clone, err := c.cloneWrapper(node, path)
+	if err != nil {
+		return nil, err
+	}
+
+	base, cloneBase := bevNodeOf(node), bevNodeOf(clone)
+	c.bases[base] = cloneBase
+	cloneBase.parentNode = parentNode
+	cloneBase.nodePrecondition = c.cloneCondition(base.nodePrecondition)
+	cloneBase.debugName = base.debugName
+	cloneBase.lastStatus, cloneBase.hasLastStatus = base.lastStatus, base.hasLastStatus
+	cloneBase.childNodeCount = base.childNodeCount
+	for i, child := range base.getChildNodes() {
+		childPath := fmt.Sprintf("%s/[%d]", path, i)
+		if child != nil {
+			childPath = path + "/" + childSegment(child, i)
+		}
+		if cloneBase.childNodeList[i], err = c.clone(child, clone, childPath); err != nil {
+			return nil, err
+		}
+	}
+	return clone, nil
+}
+
+// copy the wrappers and the wrapped node, but not the content of BevNode
+func (c *treeCloner) cloneWrapper(node IBevNode, path string) (IBevNode, error) {
+	var clone IBevNode
+	switch w := node.(type) {
+	case *BevSelector:
+		inner, err := c.cloneWrapper(w.IBevSelector, path)
+		if err != nil {
+			return nil, err
+		}
+		clone = &BevSelector{inner}
+	case *BevTerminal:
+		inner, err := c.cloneWrapper(w.IBevTerminal, path)
+		if err != nil {
+			return nil, err
+		}
+		terminal, ok := inner.(IBevTerminal)
+		if !ok {
+			return nil, fmt.Errorf("%s: clone of %T is not a terminal", path, w.IBevTerminal)
+		}
+		clone = &BevTerminal{terminal, w.nodeStatus, w.needExit}
+	case *BevReverse:
+		inner, err := c.cloneWrapper(w.IBevNode, path)
+		if err != nil {
+			return nil, err
+		}
+		clone = &BevReverse{inner}
+	case Cloner:
+		clone = w.CloneNode()
+		if reflect.TypeOf(clone) != reflect.TypeOf(node) {
+			return nil, fmt.Errorf("%s: %T does not implement CloneNode, got %T", path, node, clone)
+		}
+		if bevNodeOf(clone) == bevNodeOf(node) {
+			return nil, fmt.Errorf("%s: CloneNode of %T returned the same BevNode", path, node)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %T does not implement Cloner", path, node)
+	}
+
+	c.nodes[node] = clone
+	return clone, nil
+}
+
+func (c *treeCloner) cloneCondition(cond p.IPrecondition) p.IPrecondition {
+	if cond == nil {
+		return nil
+	}
+	if !reflect.TypeOf(cond).Comparable() {
+		return p.ClonePrecondition(cond)
+	}
+	if clone, ok := c.conds[cond]; ok {
+		return clone
+	}
+	clone := p.ClonePrecondition(cond)
+	c.conds[cond] = clone
+	return clone
+}
@@ -0,0 +1,135 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"testing"
+
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// count the frames it has been running, finish after two
+type B struct {
+	*TerminalNode
+	frames int
+}
+
+func (node *B) Enter(input interface{}) {
+	node.frames = 0
+}
+
+func (node *B) Execute(input interface{}, output interface{}) BevRunningStatus {
+	node.frames++
+	*output.(*int) = node.frames
+	if node.frames >= 2 {
+		return StateFinish
+	}
+	return StateExecuting
+}
+
+func (node *B) Exit(input interface{}, exitStatus BevRunningStatus) {
+}
+
+func (node *B) CloneNode() IBevNode {
+	return &B{NewTerminalNode(nil, nil), node.frames}
+}
+
+type counterCond struct {
+	count int
+}
+
+func (cond *counterCond) ExternalCondition(input interface{}) bool {
+	cond.count++
+	return true
+}
+
+func (cond *counterCond) ClonePrecondition() IPrecondition {
+	return &counterCond{cond.count}
+}
+
+func TestClone(t *testing.T) {
+	Convey("Clones are independent copies including run state", t, func() {
+		shared := NewPreconditionTRUE()
+		counter := &counterCond{}
+		root := NewSelector(NewSequenceSelector(nil, NewPreconditionAND(shared, counter)))
+		root.SetDebugName("root")
+		leaf := NewTerminal(&B{NewTerminalNode(root, shared), 0})
+		root.AddChildNode(leaf)
+
+		output := 0
+		So(root.Evaluate(nil), ShouldBeTrue)
+		So(root.Tick(nil, &output), ShouldEqual, StateExecuting)
+
+		clone, err := Clone(root)
+		So(err, ShouldBeNil)
+		So(Validate(clone), ShouldBeEmpty)
+		So(clone.GetDebugName(), ShouldEqual, "root")
+
+		cloneLeaf := bevNodeOf(clone).childNodeList[0]
+		So(cloneLeaf, ShouldNotEqual, leaf)
+		So(cloneLeaf.GetNodePrecondition(), ShouldEqual, shared)
+		So(clone.GetNodePrecondition(), ShouldNotEqual, root.GetNodePrecondition())
+		So(bevNodeOf(clone).activeNode, ShouldEqual, cloneLeaf)
+
+		// the clone resumes where the original was
+		So(clone.Tick(nil, &output), ShouldEqual, StateFinish)
+		So(output, ShouldEqual, 2)
+		So(root.Tick(nil, &output), ShouldEqual, StateFinish)
+		So(output, ShouldEqual, 2)
+	})
+
+	Convey("Cached preconditions are cloned with a cache of their own", t, func() {
+		cache := NewCache()
+		cache.SetFrame(3)
+		counter := &counterCond{}
+		root := NewSelector(NewSequenceSelector(nil, cache.Wrap(counter)))
+		root.AddChildNode(NewTerminal(&B{NewTerminalNode(root, nil), 0}))
+		So(root.Evaluate(nil), ShouldBeTrue)
+		So(root.Evaluate(nil), ShouldBeTrue)
+		So(counter.count, ShouldEqual, 1)
+
+		clone, err := Clone(root)
+		So(err, ShouldBeNil)
+		cloneCond := clone.GetNodePrecondition().(*PreconditionCached)
+		So(cloneCond.Cache(), ShouldNotEqual, cache)
+		So(cloneCond.Cache().Frame(), ShouldEqual, 3)
+		So(clone.Evaluate(nil), ShouldBeTrue)
+		So(clone.Evaluate(nil), ShouldBeTrue)
+		So(counter.count, ShouldEqual, 1)
+		hits, misses := cloneCond.Cache().Stats()
+		So(hits, ShouldEqual, 1)
+		So(misses, ShouldEqual, 1)
+		hits, misses = cache.Stats()
+		So(hits, ShouldEqual, 1)
+		So(misses, ShouldEqual, 1)
+	})
+
+	Convey("Terminals without CloneNode can not be cloned", t, func() {
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.AddChildNode(NewTerminal(NewA(root, nil, 1)))
+		_, err := Clone(root)
+		So(err.Error(), ShouldEqual, "PrioritySelector/A[0]: *node.A does not implement Cloner")
+	})
+}
@@ -61,6 +61,18 @@ const (
 type BevRunningStatus int
 type TerminalNodeStaus int
 
+func (status BevRunningStatus) String() string {
+	switch status {
+	case StateTransition:
+		return "transition"
+	case StateExecuting:
+		return "executing"
+	case StateFinish:
+		return "finish"
+	}
+	return fmt.Sprintf("status(%d)", int(status))
+}
+
 /*
  *
  */
@@ -79,6 +91,7 @@ type IBevNode interface {
 	PrintChild(blk int, callback ChildrenJobFunc)
 }
 
+// Deprecated: PrintbevTree prints to stdout, use RenderTree
 func PrintbevTree(root IBevNode, blk int) {
 
 	for i := 0; i < blk; i++ {
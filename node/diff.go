@@ -0,0 +1,204 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * ChangeKind
+ */
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeMoved
+	ChangeReordered
+	ChangeType
+	ChangePrecondition
+)
+
+type ChangeKind int
+
+func (kind ChangeKind) String() string {
+	switch kind {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeMoved:
+		return "moved"
+	case ChangeReordered:
+		return "reordered"
+	case ChangeType:
+		return "type"
+	case ChangePrecondition:
+		return "precondition"
+	}
+	return fmt.Sprintf("change(%d)", int(kind))
+}
+
+/*
+ * Change found by Diff, Path is in the new tree except for removed nodes.
+ * Old and New describe what changed: the parent paths of a moved node, the
+ * children of a reordered node, the types or the preconditions.
+ */
+type Change struct {
+	Kind ChangeKind
+	Path string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded, ChangeRemoved:
+		return fmt.Sprintf("%s: %s", c.Kind, c.Path)
+	}
+	return fmt.Sprintf("%s: %s: %s -> %s", c.Kind, c.Path, c.Old, c.New)
+}
+
+/*
+ * Diff compares the structure of two trees. Nodes are matched by debug name,
+ * unnamed nodes and nodes whose name is not unique by their parent and their
+ * position, eg. "attack/SequenceSelector[1]", so only named nodes can move.
+ */
+func Diff(a, b IBevNode) []Change {
+	before, after := indexNodes(a), indexNodes(b)
+	var changes []Change
+
+	for _, key := range before.keys {
+		if _, ok := after.nodes[key]; !ok {
+			changes = append(changes, Change{Kind: ChangeRemoved, Path: before.nodes[key].path})
+		}
+	}
+	for _, key := range after.keys {
+		n := after.nodes[key]
+		old, ok := before.nodes[key]
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeAdded, Path: n.path})
+			continue
+		}
+		if old.parent != n.parent {
+			changes = append(changes, Change{ChangeMoved, n.path, parentPath(old.path), parentPath(n.path)})
+		}
+		if oldType, newType := diffType(old.node), diffType(n.node); oldType != newType {
+			changes = append(changes, Change{ChangeType, n.path, oldType, newType})
+		}
+		oldCond := DescribePrecondition(bevNodeOf(old.node).nodePrecondition)
+		newCond := DescribePrecondition(bevNodeOf(n.node).nodePrecondition)
+		if oldCond != newCond {
+			changes = append(changes, Change{ChangePrecondition, n.path, oldCond, newCond})
+		}
+		if oldOrder, newOrder := keptOrder(before, after, key), keptOrder(after, before, key); oldOrder != newOrder {
+			changes = append(changes, Change{ChangeReordered, n.path, oldOrder, newOrder})
+		}
+	}
+	return changes
+}
+
+// type of a node for Diff, reversed nodes are prefixed with "!"
+func diffType(node IBevNode) string {
+	if _, ok := node.(*BevReverse); ok {
+		return "!" + NodeTypeName(node)
+	}
+	return NodeTypeName(node)
+}
+
+func parentPath(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// labels of the children of key kept under key in other, in the order of tree
+func keptOrder(tree, other *nodeIndex, key string) string {
+	var labels []string
+	for _, child := range tree.nodes[key].children {
+		n, ok := tree.nodes[child]
+		if o, found := other.nodes[child]; ok && found && o.parent == key {
+			labels = append(labels, NodeLabel(n.node))
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+type indexedNode struct {
+	node     IBevNode
+	path     string
+	parent   string
+	children []string
+}
+
+type nodeIndex struct {
+	nodes map[string]*indexedNode
+	keys  []string // depth-first order
+}
+
+func indexNodes(root IBevNode) *nodeIndex {
+	index := &nodeIndex{nodes: map[string]*indexedNode{}}
+	if root == nil {
+		return index
+	}
+
+	names := map[string]int{}
+	Walk(root, func(node IBevNode, path string, depth int) {
+		if name := node.GetDebugName(); name != "" {
+			names[name]++
+		}
+	})
+
+	var add func(node IBevNode, key, path, parent string, visited map[*BevNode]bool)
+	add = func(node IBevNode, key, path, parent string, visited map[*BevNode]bool) {
+		base := bevNodeOf(node)
+		if visited[base] {
+			return
+		}
+		visited[base] = true
+
+		n := &indexedNode{node: node, path: path, parent: parent}
+		index.nodes[key] = n
+		index.keys = append(index.keys, key)
+		for i, child := range base.getChildNodes() {
+			if child == nil {
+				continue
+			}
+			childKey := child.GetDebugName()
+			if childKey == "" || names[childKey] > 1 {
+				childKey = key + "/" + fmt.Sprintf("%s[%d]", NodeLabel(child), i)
+			}
+			n.children = append(n.children, childKey)
+			add(child, childKey, path+"/"+childSegment(child, i), key, visited)
+		}
+	}
+
+	key := root.GetDebugName()
+	if key == "" || names[key] > 1 {
+		key = NodeLabel(root)
+	}
+	add(root, key, NodeLabel(root), "", map[*BevNode]bool{})
+	return index
+}
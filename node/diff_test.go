@@ -0,0 +1,76 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"testing"
+
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func named(name string, node IBevNode, children ...IBevNode) IBevNode {
+	bevNodeOf(node).SetDebugName(name)
+	for _, child := range children {
+		bevNodeOf(node).AddChildNode(child)
+	}
+	return node
+}
+
+func leaf(name string) IBevNode {
+	return named(name, NewTerminal(&B{NewTerminalNode(nil, nil), 0}))
+}
+
+func TestDiff(t *testing.T) {
+	Convey("Diff reports the structural changes", t, func() {
+		a := named("root", NewSelector(NewPrioritySelector(nil, nil)),
+			named("attack", NewSelector(NewSequenceSelector(nil, NewPreconditionTRUE())), leaf("aim"), leaf("shoot")),
+			leaf("idle"),
+			leaf("flee"))
+		b := named("root", NewSelector(NewPrioritySelector(nil, nil)),
+			named("attack", NewSelector(NewPrioritySelector(nil, NewPreconditionFALSE())), leaf("shoot"), leaf("aim"), leaf("idle")),
+			leaf("dance"))
+
+		var changes []string
+		for _, c := range Diff(a, b) {
+			changes = append(changes, c.String())
+		}
+		So(changes, ShouldResemble, []string{
+			"removed: root/flee",
+			"type: root/attack: SequenceSelector -> PrioritySelector",
+			"precondition: root/attack: true -> false",
+			"reordered: root/attack: aim, shoot -> shoot, aim",
+			"moved: root/attack/idle: root -> root/attack",
+			"added: root/dance",
+		})
+	})
+
+	Convey("Identical trees have no change", t, func() {
+		mk := func() IBevNode {
+			return named("root", NewSelector(NewSequenceSelector(nil, nil)), leaf(""), NewReverse(leaf("")))
+		}
+		So(Diff(mk(), mk()), ShouldBeEmpty)
+		So(Diff(nil, mk()), ShouldHaveLength, 3)
+	})
+}
@@ -0,0 +1,119 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+ * ErrorPolicy tells a BevTree what to do when a node precondition returns an
+ * error, see precondition.IErrorPrecondition. The precondition is false under
+ * every policy.
+ */
+const (
+	ErrorAsFalse    ErrorPolicy = iota // the error is dropped
+	ErrorReport                        // the error is reported
+	ErrorFailBranch                    // the error is reported and the parent selector evaluates false
+)
+
+type ErrorPolicy int
+
+func (policy ErrorPolicy) String() string {
+	switch policy {
+	case ErrorAsFalse:
+		return "false"
+	case ErrorReport:
+		return "report"
+	case ErrorFailBranch:
+		return "fail"
+	}
+	return fmt.Sprintf("policy(%d)", int(policy))
+}
+
+/*
+ * Listeners which also implement IBevErrorListener get the errors reported by
+ * ErrorReport and ErrorFailBranch, node is the one whose precondition failed.
+ */
+type IBevErrorListener interface {
+	OnError(node IBevNode, err error)
+}
+
+/*
+ * SetErrorPolicy sets what a precondition error does, ErrorAsFalse by default.
+ * Under ErrorFailBranch the error fails the selector the node is a child of,
+ * instead of letting it select another child. The selector must be wrapped by
+ * NewSelector, like the builder and the loader do.
+ */
+func (tree *BevTree) SetErrorPolicy(policy ErrorPolicy) *BevTree {
+	tree.errorPolicy = policy
+	return tree
+}
+
+func (tree *BevTree) GetErrorPolicy() ErrorPolicy {
+	return tree.errorPolicy
+}
+
+// Err returns the errors reported during the last Update joined, nil if there was none
+func (tree *BevTree) Err() error {
+	return errors.Join(tree.errors...)
+}
+
+func (tree *BevTree) onError(node IBevNode, err error) {
+	if tree == nil || tree.errorPolicy == ErrorAsFalse {
+		return
+	}
+	tree.errors = append(tree.errors, err)
+	if tree.errorPolicy == ErrorFailBranch {
+		tree.failedBranch = true
+	}
+	for _, l := range tree.errorListeners {
+		l.OnError(node, err)
+	}
+}
+
+/*
+ * Under ErrorFailBranch the errors of the children of a selector are kept
+ * apart from the ones of its siblings: enterBranch clears the flag before the
+ * selector evaluates its children and returns the flag of the siblings,
+ * leaveBranch restores it and tells whether a child failed the selector.
+ */
+func (tree *BevTree) enterBranch() bool {
+	if tree == nil {
+		return false
+	}
+	siblings := tree.failedBranch
+	tree.failedBranch = false
+	return siblings
+}
+
+func (tree *BevTree) leaveBranch(siblings bool) bool {
+	if tree == nil {
+		return false
+	}
+	failed := tree.failedBranch
+	tree.failedBranch = siblings
+	return failed
+}
@@ -0,0 +1,355 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+/*
+ * Explanation tells how a node evaluates: the value of its precondition and
+ * of the sub-expressions, the children considered by a composite and the one
+ * it selects. Children which are not considered only have Path and Type.
+ */
+type Explanation struct {
+	Path         string         `json:"path"`
+	Type         string         `json:"type"`
+	Reversed     bool           `json:"reversed,omitempty"`
+	Considered   bool           `json:"considered"`
+	Result       bool           `json:"result"`
+	Precondition *p.Evaluation  `json:"precondition,omitempty"`
+	Score        *float64       `json:"score,omitempty"`
+	Selected     string         `json:"selected,omitempty"` // path of the selected child
+	Note         string         `json:"note,omitempty"`
+	Children     []*Explanation `json:"children,omitempty"`
+	node         IBevNode
+}
+
+/*
+ * Explain evaluates root with input like Evaluate does, but without changing
+ * the state of the nodes nor notifying the listeners, so it can be called
+ * between two updates of a running tree. Preconditions, scorers and Evaluate
+ * of terminals are called, they are expected to be free of side effects.
+ * The pick of RandomSelector and of weighted UtilitySelector is only predicted
+ * when they have a seed, see SetSeed.
+ */
+func Explain(root IBevNode, input interface{}) *Explanation {
+	if root == nil {
+		return nil
+	}
+	x := &explainer{input: input, explaining: map[*BevNode]bool{}}
+	return x.explain(root, NodeLabel(root))
+}
+
+type explainer struct {
+	input      interface{}
+	explaining map[*BevNode]bool // nodes being explained, to stop on cycles
+}
+
+func (x *explainer) explain(node IBevNode, path string) *Explanation {
+	e := &Explanation{Path: path, Type: NodeTypeName(node), Considered: true, node: node}
+	base := bevNodeOf(node)
+	if x.explaining[base] {
+		e.Note = "cycle, the node is its own ancestor"
+		return e
+	}
+	x.explaining[base] = true
+	defer delete(x.explaining, base)
+
+	for {
+		r, ok := node.(*BevReverse)
+		if !ok {
+			break
+		}
+		e.Reversed = !e.Reversed
+		node = r.IBevNode
+	}
+	e.Children = make([]*Explanation, base.childNodeCount)
+	for i, child := range base.getChildNodes() {
+		if child == nil {
+			e.Children[i] = &Explanation{Path: fmt.Sprintf("%s/[%d]", path, i), Type: "<nil>"}
+		} else {
+			e.Children[i] = &Explanation{Path: path + "/" + childSegment(child, i), Type: NodeTypeName(child), node: child}
+		}
+	}
+
+	e.Result = x.evaluate(e, node) != e.Reversed
+	return e
+}
+
+// explain the index-th child of node, e is the explanation of node
+func (x *explainer) child(e *Explanation, node IBevNode, index int) bool {
+	child := bevNodeOf(node).childNodeList[index]
+	if child == nil {
+		e.Children[index].Considered = true
+		return false
+	}
+	e.Children[index] = x.explain(child, e.Children[index].Path)
+	return e.Children[index].Result
+}
+
+func (x *explainer) selectChild(e *Explanation, index int) bool {
+	e.Selected = e.Children[index].Path
+	return true
+}
+
+// result of node, which is stripped of its BevReverse wrappers
+func (x *explainer) evaluate(e *Explanation, node IBevNode) bool {
+	var inner IBevNode
+	switch w := node.(type) {
+	case *BevSelector:
+		inner = w.IBevSelector
+	case *BevTerminal:
+		inner = w.IBevTerminal
+	}
+	if inner != nil {
+		if cond := node.GetNodePrecondition(); cond != nil {
+			e.Precondition = p.Evaluate(cond, x.input)
+			if !e.Precondition.Value {
+				return false
+			}
+		}
+		return x.evaluate(e, inner)
+	}
+
+	switch n := node.(type) {
+	case *UtilitySelector:
+		return x.utility(e, n)
+	case *NonePrioritySelector:
+		if n.checkIndex(n.currentSelectIndex) && x.child(e, n, n.currentSelectIndex) {
+			e.Note = "the running child is kept while it evaluates true"
+			return x.selectChild(e, n.currentSelectIndex)
+		}
+		return x.priority(e, n.PrioritySelector)
+	case *RandomSelector:
+		if n.seeded != nil && n.childNodeCount > 0 {
+			// draw from a copy, the source of the selector is not advanced
+			seeded := *n.seeded
+			index := seeded.intn(n.childNodeCount)
+			e.Note = fmt.Sprintf("child %d is picked by the seeded source", index)
+			if !x.child(e, n, index) {
+				return false
+			}
+			return x.selectChild(e, index)
+		}
+		result := false
+		for i := 0; i < n.childNodeCount; i++ {
+			result = x.child(e, n, i) || result
+		}
+		e.Note = "one child is picked at random, it is selected if it evaluates true"
+		return result
+	case *PrioritySelector:
+		return x.priority(e, n)
+	case *SequenceSelector:
+		index := n.currentSelectIndex
+		if !n.checkIndex(index) {
+			index = 0
+		}
+		if !n.checkIndex(index) {
+			return false
+		}
+		e.Note = fmt.Sprintf("the sequence is at child %d", index)
+		if !x.child(e, n, index) {
+			return false
+		}
+		return x.selectChild(e, index)
+	case *ParallelSelector:
+		for i := 0; i < n.childNodeCount; i++ {
+			if !x.child(e, n, i) {
+				return false
+			}
+		}
+		return true
+	case *LoopSelector:
+		if n.loopCount == ConstInfiniteLoop {
+			e.Note = fmt.Sprintf("loop=%d/inf", n.currentCount)
+		} else {
+			e.Note = fmt.Sprintf("loop=%d/%d", n.currentCount, n.loopCount)
+		}
+		if n.loopCount == ConstInfiniteLoop || n.currentCount <= n.loopCount || !n.checkIndex(0) {
+			return false
+		}
+		if !x.child(e, n, 0) {
+			return false
+		}
+		return x.selectChild(e, 0)
+	}
+
+	if bevNodeOf(node).childNodeCount > 0 {
+		e.Note = "not a builtin composite, it is not evaluated"
+		return false
+	}
+	return node.Evaluate(x.input)
+}
+
+func (x *explainer) priority(e *Explanation, n *PrioritySelector) bool {
+	for i := 0; i < n.childNodeCount; i++ {
+		if x.child(e, n, i) {
+			return x.selectChild(e, i)
+		}
+	}
+	return false
+}
+
+func (x *explainer) utility(e *Explanation, n *UtilitySelector) bool {
+	scores := make([]float64, n.childNodeCount)
+	order := make([]int, n.childNodeCount)
+	for i := range scores {
+		scores[i] = n.score(i, x.input)
+		order[i] = i
+		// NaN and infinite scores can not be written as json
+		if score := scores[i]; !math.IsNaN(score) && !math.IsInf(score, 0) {
+			e.Children[i].Score = &score
+		}
+	}
+	explained := func(i int) bool {
+		score := e.Children[i].Score
+		result := x.child(e, n, i)
+		e.Children[i].Score = score
+		return result
+	}
+
+	if n.mode == UtilityWeightedRandom {
+		var candidates []int
+		var weights []float64
+		total := 0.0
+		for i := 0; i < n.childNodeCount; i++ {
+			if explained(i) {
+				weight := math.Max(n.adjustScore(i, scores[i]), 0)
+				candidates = append(candidates, i)
+				weights = append(weights, weight)
+				total += weight
+			}
+		}
+		if n.seeded == nil {
+			e.Note = "one of the children evaluating true is picked at random, weighted by score"
+			return len(candidates) > 0
+		}
+		if len(candidates) == 0 {
+			return false
+		}
+		draw := 0.0
+		if total > 0 {
+			seeded := *n.seeded
+			draw = seeded.float64()
+		}
+		e.Note = "picked by the seeded source, weighted by score"
+		return x.selectChild(e, weightedPick(candidates, weights, draw*total))
+	}
+
+	e.Note = "children are considered by score, momentum included"
+	// stable insertion sort, best first, like the sort of Evaluate
+	for i := 1; i < len(order); i++ {
+		for j := i; j > 0 && n.adjustScore(order[j], scores[order[j]]) > n.adjustScore(order[j-1], scores[order[j-1]]); j-- {
+			order[j], order[j-1] = order[j-1], order[j]
+		}
+	}
+	for _, i := range order {
+		if explained(i) {
+			return x.selectChild(e, i)
+		}
+	}
+	return false
+}
+
+/*
+ * WriteText writes the explanation with one line per node and per precondition
+ * sub-expression, like
+ *
+ *	root (PrioritySelector): true, selected root/idle
+ *	  attack (SequenceSelector): false
+ *	    when and($1 < 3, exists($2)): false
+ *	      $1 < 3: true
+ *	      exists($2): false
+ *	    aim (AimNode): not considered
+ *	  idle (IdleNode): true
+ */
+func (e *Explanation) WriteText(w io.Writer) error {
+	var b strings.Builder
+	e.writeText(&b, "")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (e *Explanation) String() string {
+	var buf bytes.Buffer
+	e.WriteText(&buf)
+	return buf.String()
+}
+
+func (e *Explanation) writeText(b *strings.Builder, indent string) {
+	label := e.Path[strings.LastIndex(e.Path, "/")+1:]
+	b.WriteString(indent + label)
+	if e.Type != label {
+		fmt.Fprintf(b, " (%s)", e.Type)
+	}
+	if e.Reversed {
+		b.WriteString(" reversed")
+	}
+	if !e.Considered {
+		b.WriteString(": not considered\n")
+		return
+	}
+	fmt.Fprintf(b, ": %v", e.Result)
+	if e.Selected != "" {
+		b.WriteString(", selected " + e.Selected)
+	}
+	if e.Score != nil {
+		b.WriteString(", score " + strconv.FormatFloat(*e.Score, 'g', 3, 64))
+	}
+	if e.Note != "" {
+		b.WriteString(" (" + e.Note + ")")
+	}
+	b.WriteString("\n")
+
+	if e.Precondition != nil {
+		writeCondition(b, e.Precondition, indent+"  ", "when ")
+	}
+	for _, child := range e.Children {
+		child.writeText(b, indent+"  ")
+	}
+}
+
+func writeCondition(b *strings.Builder, cond *p.Evaluation, indent string, prefix string) {
+	b.WriteString(indent + prefix + cond.Expr + ": ")
+	switch {
+	case cond.Skipped:
+		b.WriteString("skipped")
+	case cond.Error != "":
+		b.WriteString("error: " + cond.Error)
+	default:
+		fmt.Fprintf(b, "%v", cond.Value)
+	}
+	b.WriteString("\n")
+	for _, operand := range cond.Operands {
+		writeCondition(b, operand, indent+"  ", "")
+	}
+}
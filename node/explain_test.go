@@ -0,0 +1,113 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"encoding/json"
+	"testing"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	"github.com/ShionRyuu/gobevtree/precondition/expr"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExplain(t *testing.T) {
+	Convey("Explain tells which child is selected and why", t, func() {
+		fight := false
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.SetDebugName("root")
+		attack := NewSelector(NewSequenceSelector(root, NewPreconditionAND(flagCond{&fight}, NewPreconditionTRUE())))
+		attack.SetDebugName("attack")
+		attack.AddChildNode(NewTerminal(NewA(attack, nil, 1)))
+		root.AddChildNode(attack)
+		idle := NewTerminal(NewA(root, Not(NewPreconditionFALSE()), 2))
+		idle.SetDebugName("idle")
+		root.AddChildNode(idle)
+		root.AddChildNode(NewReverse(NewTerminal(NewA(root, nil, 3))))
+
+		r := &recorder{}
+		tree := NewBevTree(root).AddListener(r)
+		e := Explain(root, nil)
+		So(r.events, ShouldBeEmpty)
+		So(root.IBevSelector.(*PrioritySelector).currentSelectIndex, ShouldEqual, ConstInvalidChildNodeIndex)
+		So(e.Result, ShouldBeTrue)
+		So(e.Selected, ShouldEqual, "root/idle")
+		So(e.String(), ShouldEqual, `root (PrioritySelector): true, selected root/idle
+  attack (SequenceSelector): false
+    when and(flagCond, true): false
+      flagCond: false
+      true: skipped
+    A[0] (A): not considered
+  idle (A): true
+    when not(false): true
+      false: false
+  A[2] (A): not considered
+`)
+
+		data, err := json.Marshal(e)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, `"selected":"root/idle"`)
+
+		fight = true
+		e = Explain(root, nil)
+		So(e.Selected, ShouldEqual, "root/attack")
+		So(e.Children[1].Considered, ShouldBeFalse)
+		output := 0
+		tree.Update(nil, &output)
+		So(output, ShouldEqual, 1)
+	})
+
+	Convey("Reversed nodes and utility scores are explained", t, func() {
+		scoreA, scoreB, condA := 0.9, 0.5, false
+		root, _, _, _ := newUtilityTree(&scoreA, &scoreB, &condA)
+		e := Explain(root, nil)
+		So(e.Selected, ShouldEqual, "root/b")
+		So(*e.Children[0].Score, ShouldEqual, 0.9)
+		So(e.Children[0].Result, ShouldBeFalse)
+		So(*e.Children[1].Score, ShouldEqual, 0.5)
+
+		reversed := NewReverse(NewTerminal(NewA(nil, NewPreconditionFALSE(), 1)))
+		e = Explain(reversed, nil)
+		So(e.Reversed, ShouldBeTrue)
+		So(e.Result, ShouldBeTrue)
+		So(Explain(nil, nil), ShouldBeNil)
+	})
+
+	Convey("Expression guards are explained operand by operand", t, func() {
+		env := expr.NewEnv().Declare("hp", 1, expr.Int)
+		flee := NewTerminal(NewA(nil, expr.MustCompile("hp < 30 && !(hp > 3)", env), 1))
+		flee.SetDebugName("flee")
+		board := bb.NewBlackboard()
+		board.SetValueAsInt(1, 2)
+		e := Explain(flee, board)
+		So(e.Result, ShouldBeTrue)
+		So(e.String(), ShouldEqual, `flee (A): true
+  when hp < 30 && !(hp > 3): true
+    $1 < 30: true
+    not($1 > 3): true
+      $1 > 3: false
+`)
+	})
+}
@@ -0,0 +1,79 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"time"
+)
+
+/*
+ * IBevListener is notified of what the nodes of a BevTree do. Evaluate, tick and
+ * transition are reported by the parent of the node, or by the tree for its root,
+ * enter and exit by BevTerminal. node is the child as added to its parent, so it
+ * is usually a wrapper created by NewSelector or NewTerminal.
+ */
+type IBevListener interface {
+	OnEvaluate(node IBevNode, result bool)
+	OnEnter(node IBevNode)
+	OnTick(node IBevNode, status BevRunningStatus)
+	OnExit(node IBevNode, status BevRunningStatus)
+	OnTransition(node IBevNode)
+}
+
+/*
+ * Listeners which also implement IBevExecuteListener get the time spent in
+ * every Execute of a terminal, Execute is only timed when one is attached.
+ */
+type IBevExecuteListener interface {
+	OnExecute(node IBevNode, status BevRunningStatus, elapsed time.Duration)
+}
+
+/*
+ * Listeners which also implement IBevPreconditionListener get the result of
+ * every node precondition evaluated, node is the wrapper owning it. Unlike
+ * OnEvaluate, a composite without precondition finding no runnable child is
+ * not reported.
+ */
+type IBevPreconditionListener interface {
+	OnPrecondition(node IBevNode, result bool)
+}
+
+// BevListener does nothing, embed it to implement only some of the callbacks
+type BevListener struct {
+}
+
+func (l BevListener) OnEvaluate(node IBevNode, result bool) {
+}
+
+func (l BevListener) OnEnter(node IBevNode) {
+}
+
+func (l BevListener) OnTick(node IBevNode, status BevRunningStatus) {
+}
+
+func (l BevListener) OnExit(node IBevNode, status BevRunningStatus) {
+}
+
+func (l BevListener) OnTransition(node IBevNode) {
+}
@@ -0,0 +1,225 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+/*
+ * LogOptions of BevTree.SetLogger
+ */
+type LogOptions struct {
+	Agent string       // value of the "agent" attribute, omitted when empty
+	Level slog.Leveler // records below it are dropped, slog.LevelInfo when nil
+}
+
+/*
+ * SetLogger attaches a listener writing structured records to logger, at
+ * slog.LevelWarn when a precondition error is reported (see SetErrorPolicy), at
+ * slog.LevelInfo when a selector switches to another branch, at slog.LevelDebug
+ * when a precondition fails and when a terminal is entered or exited. Records
+ * have the "frame" and "path" attributes, nil logger detaches the logger. See
+ * BoardLogger for the type errors of the blackboard.
+ */
+func (tree *BevTree) SetLogger(logger *slog.Logger, opts LogOptions) *BevTree {
+	if tree.logger != nil {
+		tree.RemoveListener(tree.logger)
+		tree.logger = nil
+	}
+	if logger == nil {
+		return tree
+	}
+	if opts.Agent != "" {
+		logger = logger.With(slog.String("agent", opts.Agent))
+	}
+	level := opts.Level
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	tree.logger = &treeLogger{
+		tree:       tree,
+		frame:      tree.frame,
+		logger:     logger,
+		level:      level,
+		paths:      map[IBevNode]string{},
+		nodes:      map[string]IBevNode{},
+		lastBranch: map[string]string{},
+	}
+	tree.AddListener(tree.logger)
+	return tree
+}
+
+type treeLogger struct {
+	BevListener
+	tree   *BevTree
+	logger *slog.Logger
+	level  slog.Leveler
+	// guards the fields below, read by the BoardLogger from other goroutines
+	mu      sync.Mutex
+	frame   int
+	current IBevNode // node evaluated or executed
+	paths   map[IBevNode]string
+	nodes   map[string]IBevNode
+	// path of the child last ticked by a selector, by path of the selector
+	lastBranch map[string]string
+}
+
+func (l *treeLogger) setFrame(frame int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.frame = frame
+	l.mu.Unlock()
+}
+
+func (l *treeLogger) setCurrent(node IBevNode) IBevNode {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev := l.current
+	l.current = node
+	return prev
+}
+
+func (l *treeLogger) path(node IBevNode) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pathLocked(node)
+}
+
+func (l *treeLogger) pathLocked(node IBevNode) string {
+	path, ok := l.paths[node]
+	if !ok {
+		l.paths = Paths(l.tree.GetRoot())
+		for n, p := range l.paths {
+			l.nodes[p] = n
+		}
+		if path, ok = l.paths[node]; !ok {
+			path = NodeLabel(node)
+		}
+	}
+	return path
+}
+
+func (l *treeLogger) log(level slog.Level, msg string, node IBevNode, attrs ...slog.Attr) {
+	if level < l.level.Level() || !l.logger.Enabled(context.Background(), level) {
+		return
+	}
+	attrs = append([]slog.Attr{slog.Int("frame", l.tree.GetFrame()), slog.String("path", l.path(node))}, attrs...)
+	l.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+func (l *treeLogger) OnPrecondition(node IBevNode, result bool) {
+	if !result {
+		l.log(slog.LevelDebug, "precondition failed", node)
+	}
+}
+
+func (l *treeLogger) OnError(node IBevNode, err error) {
+	l.log(slog.LevelWarn, "precondition error", node, slog.String("error", err.Error()))
+}
+
+func (l *treeLogger) OnEnter(node IBevNode) {
+	l.log(slog.LevelDebug, "terminal enter", node)
+}
+
+func (l *treeLogger) OnExit(node IBevNode, status BevRunningStatus) {
+	l.log(slog.LevelDebug, "terminal exit", node, slog.String("status", status.String()))
+}
+
+func (l *treeLogger) OnTick(node IBevNode, status BevRunningStatus) {
+	if slog.LevelInfo < l.level.Level() {
+		return
+	}
+	path := l.path(node)
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return
+	}
+	parent := path[:i]
+	l.mu.Lock()
+	parentNode := l.nodes[parent]
+	l.mu.Unlock()
+	switch unwrapNode(parentNode).(type) {
+	case *PrioritySelector, *NonePrioritySelector, *RandomSelector, *UtilitySelector:
+	default:
+		return
+	}
+	if last, ok := l.lastBranch[parent]; ok && last != path {
+		l.log(slog.LevelInfo, "branch switch", parentNode, slog.String("from", last), slog.String("to", path))
+	}
+	l.lastBranch[parent] = path
+}
+
+/*
+ * BoardLogger returns a logger for blackboard.SetLogger writing to the logger
+ * of the tree, nil when there is none. Its records have the "agent" attribute
+ * and the "frame" and "path" of the node being evaluated or executed:
+ *
+ *	tree.SetLogger(logger, node.LogOptions{Agent: "npc-1"})
+ *	board.SetLogger(tree.BoardLogger())
+ */
+func (tree *BevTree) BoardLogger() *slog.Logger {
+	if tree.logger == nil {
+		return nil
+	}
+	return slog.New(&nodeHandler{tree.logger.logger.Handler(), tree.logger})
+}
+
+// adds the frame and path of the current node of the tree to the records
+type nodeHandler struct {
+	slog.Handler
+	l *treeLogger
+}
+
+func (h *nodeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.l.level.Level() && h.Handler.Enabled(ctx, level)
+}
+
+func (h *nodeHandler) Handle(ctx context.Context, r slog.Record) error {
+	record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	h.l.mu.Lock()
+	record.AddAttrs(slog.Int("frame", h.l.frame))
+	if h.l.current != nil {
+		record.AddAttrs(slog.String("path", h.l.pathLocked(h.l.current)))
+	}
+	h.l.mu.Unlock()
+	r.Attrs(func(a slog.Attr) bool {
+		record.AddAttrs(a)
+		return true
+	})
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *nodeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &nodeHandler{h.Handler.WithAttrs(attrs), h.l}
+}
+
+func (h *nodeHandler) WithGroup(name string) slog.Handler {
+	return &nodeHandler{h.Handler.WithGroup(name), h.l}
+}
@@ -0,0 +1,152 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type flagCond struct {
+	flag *bool
+}
+
+func (cond flagCond) ExternalCondition(input interface{}) bool {
+	return *cond.flag
+}
+
+func newLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestLogger(t *testing.T) {
+	Convey("Branch switches and terminal events are logged", t, func() {
+		fight := true
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.SetDebugName("root")
+		b := NewTerminal(&B{NewTerminalNode(root, flagCond{&fight}), 0})
+		b.SetDebugName("fight")
+		idle := NewTerminal(NewA(root, NewPreconditionTRUE(), 1))
+		idle.SetDebugName("idle")
+		root.AddChildNode(b)
+		root.AddChildNode(idle)
+
+		var buf bytes.Buffer
+		tree := NewBevTree(root)
+		run := func() {
+			output := 0
+			tree.Update(nil, &output)
+			fight = false
+			tree.Update(nil, &output)
+		}
+
+		Convey("Info level only has branch switches", func() {
+			tree.SetLogger(newLogger(&buf), LogOptions{Agent: "npc"})
+			run()
+			So(buf.String(), ShouldEqual, "level=INFO msg=\"branch switch\" agent=npc frame=1 path=root from=root/fight to=root/idle\n")
+		})
+
+		Convey("Debug level has the terminals and the failed preconditions", func() {
+			tree.SetLogger(newLogger(&buf), LogOptions{Level: slog.LevelDebug})
+			run()
+			So(strings.Split(strings.TrimSpace(buf.String()), "\n"), ShouldResemble, []string{
+				"level=DEBUG msg=\"terminal enter\" frame=0 path=root/fight",
+				"level=DEBUG msg=\"precondition failed\" frame=1 path=root/fight",
+				"level=DEBUG msg=\"terminal exit\" frame=1 path=root/fight status=transition",
+				"level=DEBUG msg=\"terminal enter\" frame=1 path=root/idle",
+				"level=DEBUG msg=\"terminal exit\" frame=1 path=root/idle status=finish",
+				"level=INFO msg=\"branch switch\" frame=1 path=root from=root/fight to=root/idle",
+			})
+		})
+
+		Convey("Composites without precondition are not reported as failed", func() {
+			tree.SetLogger(newLogger(&buf), LogOptions{Level: slog.LevelDebug})
+			root.SetNodePrecondition(nil)
+			fight = false
+			idle.SetNodePrecondition(NewPreconditionFALSE())
+			output := 0
+			So(tree.Update(nil, &output), ShouldEqual, StateTransition)
+			So(strings.Split(strings.TrimSpace(buf.String()), "\n"), ShouldResemble, []string{
+				"level=DEBUG msg=\"precondition failed\" frame=0 path=root/fight",
+				"level=DEBUG msg=\"precondition failed\" frame=0 path=root/idle",
+			})
+		})
+
+		Convey("Blackboard type errors have the agent, frame and path", func() {
+			tree.SetLogger(newLogger(&buf), LogOptions{Agent: "npc"})
+			board := bb.NewBlackboard().SetLogger(tree.BoardLogger())
+			board.SetValueAsString(1, "full")
+			fight = true
+			b.SetNodePrecondition(CompareInt(1, OpLess, 30))
+			output := 0
+			tree.Update(board, &output)
+			So(buf.String(), ShouldEqual, "level=WARN msg=\"blackboard type error\" agent=npc frame=0 path=root/fight key=1 want=int got=string\n")
+			So(NewBevTree(root).BoardLogger(), ShouldBeNil)
+		})
+
+		Convey("The board logger can be used by other goroutines, run with -race", func() {
+			tree.SetLogger(newLogger(&buf), LogOptions{Agent: "npc"})
+			board := bb.NewBlackboard().SetLogger(tree.BoardLogger())
+			board.SetValueAsString(1, "full")
+			fight = true
+			b.SetNodePrecondition(CompareInt(1, OpLess, 30))
+			const frames = 100
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < frames; i++ {
+					board.GetValueAsInt(1)
+				}
+			}()
+			output := 0
+			for i := 0; i < frames; i++ {
+				tree.Update(board, &output)
+			}
+			wg.Wait()
+			So(strings.Count(buf.String(), "blackboard type error"), ShouldEqual, 2*frames)
+		})
+
+		Convey("nil logger detaches it", func() {
+			tree.SetLogger(newLogger(&buf), LogOptions{}).SetLogger(nil, LogOptions{})
+			run()
+			So(buf.String(), ShouldBeEmpty)
+		})
+	})
+}
@@ -46,7 +46,7 @@ func (node *LoopSelector) Evaluate(input interface{}) bool {
 	if !checkLoop {
 		return false
 	} else if node.checkIndex(0) {
-		return node.childNodeList[0].Evaluate(input)
+		return node.evaluateChild(0, input)
 	} else {
 		return false
 	}
@@ -54,12 +54,12 @@ func (node *LoopSelector) Evaluate(input interface{}) bool {
 
 func (node *LoopSelector) Transition(input interface{}) {
 	if node.checkIndex(0) {
-		node.childNodeList[0].Transition(input)
+		node.transitionChild(0, input)
 	}
 }
 
 func (node *LoopSelector) Tick(input interface{}, output interface{}) BevRunningStatus {
-	if node.checkIndex(0) && node.childNodeList[0].Tick(input, output) == StateFinish {
+	if node.checkIndex(0) && node.tickChild(0, input, output) == StateFinish {
 		node.currentCount = node.currentCount + 1
 	}
 
@@ -69,3 +69,7 @@ func (node *LoopSelector) Tick(input interface{}, output interface{}) BevRunning
 		return StateExecuting
 	}
 }
+
+func (node *LoopSelector) CloneNode() IBevNode {
+	return &LoopSelector{NewBevNode(nil, nil), node.loopCount, node.currentCount}
+}
@@ -40,6 +40,9 @@ type BevNode struct {
 	childNodeCount   int
 	debugName        string
 	childNodeList    [ConstMaxChildNodeCnt]IBevNode
+	tree             *BevTree
+	lastStatus       BevRunningStatus
+	hasLastStatus    bool
 }
 
 func NewBevNode(parentNode IBevNode, nodePrecondition p.IPrecondition) *BevNode {
@@ -66,6 +69,9 @@ func (node *BevNode) AddChildNode(childNode IBevNode) *BevNode {
 
 	node.childNodeList[node.childNodeCount] = childNode
 	node.childNodeCount += 1
+	if node.tree != nil && childNode != nil {
+		bevNodeOf(childNode).setTree(node.tree)
+	}
 	return node
 }
 
@@ -78,6 +84,25 @@ func (node *BevNode) GetNodePrecondition() p.IPrecondition {
 	return node.nodePrecondition
 }
 
+// evaluate cond, the precondition of owner, through the precondition cache of
+// the tree if it has one, errors are handled by the error policy of the tree
+func (node *BevNode) evaluatePrecondition(owner IBevNode, cond p.IPrecondition, input interface{}) bool {
+	var result bool
+	var err error
+	prev := node.tree.setCurrent(owner)
+	if node.tree != nil && node.tree.cache != nil {
+		result, err = node.tree.cache.Check(cond, input)
+	} else {
+		result, err = p.Check(cond, input)
+	}
+	node.tree.setCurrent(prev)
+	if err != nil {
+		node.tree.onError(owner, err)
+	}
+	node.tree.onPrecondition(owner, result)
+	return result
+}
+
 func (node *BevNode) GetDebugName() string {
 	return node.debugName
 }
@@ -87,6 +112,19 @@ func (node *BevNode) SetDebugName(debugName string) *BevNode {
 	return node
 }
 
+func (node *BevNode) GetParentNode() IBevNode {
+	return node.parentNode
+}
+
+func (node *BevNode) SetParentNode(parentNode IBevNode) *BevNode {
+	node.parentNode = parentNode
+	return node
+}
+
+func (node *BevNode) GetActiveNode() IBevNode {
+	return node.activeNode
+}
+
 func (node *BevNode) GetLastActiveNode() IBevNode {
 	return node.lastActiveNode
 }
@@ -119,10 +157,75 @@ func (node *BevNode) getChildNodeCount() int {
 	return node.childNodeCount
 }
 
+func (node *BevNode) getBevNode() *BevNode {
+	return node
+}
+
+// implemented by the nodes embedding BevNode and by the wrappers
+type bevNodeHolder interface {
+	getBevNode() *BevNode
+}
+
+// the BevNode of node, an empty one for the nodes implementing IBevNode
+// without embedding BevNode, they are seen as leaves without run state
+func bevNodeOf(node IBevNode) *BevNode {
+	if holder, ok := node.(bevNodeHolder); ok {
+		return holder.getBevNode()
+	}
+	return &BevNode{}
+}
+
+func (node *BevNode) getChildNodes() []IBevNode {
+	return node.childNodeList[:node.childNodeCount]
+}
+
+// set parentNode and re-parent the whole subtree
 func (node *BevNode) Reconstruct(parentNode IBevNode) {
 	node.parentNode = parentNode
-	node.childNodeCount = len(node.childNodeList)
-	for _, childNode := range node.childNodeList {
-		childNode.Reconstruct(node)
+	for i := 0; i < node.childNodeCount; i++ {
+		if childNode := node.childNodeList[i]; childNode != nil {
+			childNode.Reconstruct(node)
+		}
 	}
 }
+
+// attach the subtree to tree
+func (node *BevNode) setTree(tree *BevTree) {
+	node.tree = tree
+	for i := 0; i < node.childNodeCount; i++ {
+		if childNode := node.childNodeList[i]; childNode != nil && bevNodeOf(childNode).tree != tree {
+			bevNodeOf(childNode).setTree(tree)
+		}
+	}
+}
+
+/*
+ * Run the child at index and notify the listeners of the tree
+ */
+func (node *BevNode) evaluateChild(index int, input interface{}) bool {
+	childNode := node.childNodeList[index]
+	result := childNode.Evaluate(input)
+	node.tree.onEvaluate(childNode, result)
+	return result
+}
+
+func (node *BevNode) tickChild(index int, input interface{}, output interface{}) BevRunningStatus {
+	childNode := node.childNodeList[index]
+	status := childNode.Tick(input, output)
+	bevNodeOf(childNode).setLastStatus(status)
+	node.tree.onTick(childNode, status)
+	return status
+}
+
+func (node *BevNode) transitionChild(index int, input interface{}) {
+	childNode := node.childNodeList[index]
+	childNode.Transition(input)
+	bevNodeOf(childNode).setLastStatus(StateTransition)
+	node.tree.onTransition(childNode)
+}
+
+// status of the last tick or transition, recorded by the parent or the tree
+func (node *BevNode) setLastStatus(status BevRunningStatus) {
+	node.lastStatus = status
+	node.hasLastStatus = true
+}
@@ -40,10 +40,13 @@ func NewNonePrioritySelector(parentNode IBevNode, nodePrecondition p.IPreconditi
 
 func (node *NonePrioritySelector) Evaluate(input interface{}) bool {
 	if node.checkIndex(node.currentSelectIndex) {
-		curNode := node.childNodeList[node.currentSelectIndex]
-		if curNode.Evaluate(input) {
+		if node.evaluateChild(node.currentSelectIndex, input) {
 			return true
 		}
 	}
-	return node.Evaluate(input)
+	return node.PrioritySelector.Evaluate(input)
+}
+
+func (node *NonePrioritySelector) CloneNode() IBevNode {
+	return &NonePrioritySelector{node.PrioritySelector.CloneNode().(*PrioritySelector)}
 }
@@ -0,0 +1,49 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestNonePriority(t *testing.T) {
+	Convey("NonePriority keeps the running child and falls back to the priority order", t, func() {
+		running := true
+		selector := NewSelector(NewNonePrioritySelector(nil, nil))
+		selector.AddChildNode(NewTerminal(NewA(selector, flagCond{&running}, 1)))
+		selector.AddChildNode(NewTerminal(NewA(selector, nil, 2)))
+
+		output := 0
+		So(selector.Evaluate(nil), ShouldBeTrue)
+		selector.Tick(nil, &output)
+		So(output, ShouldEqual, 1)
+
+		// the current child fails, evaluation goes on with the others
+		running = false
+		So(selector.Evaluate(nil), ShouldBeTrue)
+		selector.Tick(nil, &output)
+		So(output, ShouldEqual, 2)
+	})
+}
@@ -40,7 +40,7 @@ func NewParallelSelector(parentNode IBevNode, nodePrecondition p.IPrecondition)
 
 func (node *ParallelSelector) Evaluate(input interface{}) bool {
 	for i := 0; i < node.childNodeCount; i++ {
-		if !node.childNodeList[i].Evaluate(input) {
+		if !node.evaluateChild(i, input) {
 			return false
 		}
 	}
@@ -49,15 +49,19 @@ func (node *ParallelSelector) Evaluate(input interface{}) bool {
 
 func (node *ParallelSelector) Transition(input interface{}) {
 	for i := 0; i < node.childNodeCount; i++ {
-		node.childNodeList[i].Transition(input)
+		node.transitionChild(i, input)
 	}
 }
 
 func (node *ParallelSelector) Tick(input interface{}, output interface{}) BevRunningStatus {
 	for i := 0; i < node.childNodeCount; i++ {
-		if node.childNodeList[i].Tick(input, output) != StateFinish {
+		if node.tickChild(i, input, output) != StateFinish {
 			return StateExecuting
 		}
 	}
 	return StateFinish
 }
+
+func (node *ParallelSelector) CloneNode() IBevNode {
+	return NewParallelSelector(nil, nil)
+}
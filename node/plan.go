@@ -0,0 +1,119 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"strings"
+)
+
+/*
+ * Plan is what the next Update of a tree would do, see BevTree.Plan
+ */
+type Plan struct {
+	Evaluates bool // the root evaluates true and is ticked, it is transitioned otherwise
+	// terminals ticked in order, several under a ParallelSelector where the
+	// later ones are only ticked when the earlier ones finish
+	Leaves []IBevNode
+	// running terminals which would be exited by a transition
+	Exits []IBevNode
+	// selectors picking a child at random without a seed, see SetSeed, the
+	// leaves under them are not predicted
+	Unpredictable []IBevNode
+	Explanation   *Explanation
+
+	unpredictablePaths []string
+}
+
+// Leaf returns the first terminal ticked, nil when none is predicted
+func (plan *Plan) Leaf() IBevNode {
+	if len(plan.Leaves) == 0 {
+		return nil
+	}
+	return plan.Leaves[0]
+}
+
+/*
+ * Plan predicts the terminals the next Update with input would tick and the
+ * running ones it would exit. Like Explain, it leaves the nodes, the listeners
+ * and the random sources of the tree untouched, so it can be called for
+ * previews and tests between updates.
+ */
+func (tree *BevTree) Plan(input interface{}) *Plan {
+	plan := &Plan{}
+	if tree.root == nil {
+		return plan
+	}
+	plan.Explanation = Explain(tree.root, input)
+	plan.Evaluates = plan.Explanation.Result
+	if plan.Evaluates {
+		plan.follow(plan.Explanation)
+	}
+
+	ticked := map[IBevNode]bool{}
+	for _, leaf := range plan.Leaves {
+		ticked[leaf] = true
+	}
+	Walk(tree.root, func(node IBevNode, path string, depth int) {
+		if isRunning(node) && !ticked[node] && !plan.underUnpredictable(path) {
+			plan.Exits = append(plan.Exits, node)
+		}
+	})
+	return plan
+}
+
+// collect the leaves ticked under the node of e, which evaluates true
+func (plan *Plan) follow(e *Explanation) {
+	if len(e.Children) == 0 {
+		plan.Leaves = append(plan.Leaves, e.node)
+		return
+	}
+	if e.Selected != "" {
+		for _, child := range e.Children {
+			if child.Path == e.Selected {
+				plan.follow(child)
+			}
+		}
+		return
+	}
+
+	switch unwrapNode(e.node).(type) {
+	case *ParallelSelector:
+		for _, child := range e.Children {
+			plan.follow(child)
+		}
+	case *RandomSelector, *UtilitySelector:
+		plan.Unpredictable = append(plan.Unpredictable, e.node)
+		plan.unpredictablePaths = append(plan.unpredictablePaths, e.Path)
+	}
+}
+
+// whether path is under an unpredictable selector, its running terminal may be kept
+func (plan *Plan) underUnpredictable(path string) bool {
+	for _, prefix := range plan.unpredictablePaths {
+		if strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"testing"
+
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// records the ticked terminals
+type leafRecorder struct {
+	BevListener
+	ticked []IBevNode
+}
+
+func (r *leafRecorder) OnTick(node IBevNode, status BevRunningStatus) {
+	if _, ok := node.(*BevTerminal); ok {
+		r.ticked = append(r.ticked, node)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	Convey("Plan predicts the ticked terminal and the exited one", t, func() {
+		fight := true
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.SetDebugName("root")
+		attack := NewTerminal(&B{NewTerminalNode(root, flagCond{&fight}), 0})
+		attack.SetDebugName("attack")
+		idle := NewTerminal(&B{NewTerminalNode(root, nil), 0})
+		idle.SetDebugName("idle")
+		root.AddChildNode(attack)
+		root.AddChildNode(idle)
+		r := &recorder{}
+		tree := NewBevTree(root).AddListener(r)
+
+		plan := tree.Plan(nil)
+		So(plan.Evaluates, ShouldBeTrue)
+		So(plan.Leaf(), ShouldEqual, attack)
+		So(plan.Exits, ShouldBeEmpty)
+		So(r.events, ShouldBeEmpty)
+
+		output := 0
+		tree.Update(nil, &output)
+		So(ActiveLeaf(root), ShouldEqual, attack)
+
+		fight = false
+		plan = tree.Plan(nil)
+		So(plan.Leaves, ShouldResemble, []IBevNode{idle})
+		So(plan.Exits, ShouldResemble, []IBevNode{attack})
+		So(ActiveLeaf(root), ShouldEqual, attack)
+		tree.Update(nil, &output)
+		So(ActiveLeaf(root), ShouldEqual, idle)
+	})
+
+	Convey("A root evaluating false ticks nothing", t, func() {
+		root := NewSelector(NewPrioritySelector(nil, NewPreconditionFALSE()))
+		root.AddChildNode(NewTerminal(NewA(root, nil, 1)))
+		plan := NewBevTree(root).Plan(nil)
+		So(plan.Evaluates, ShouldBeFalse)
+		So(plan.Leaf(), ShouldBeNil)
+	})
+
+	Convey("Seeded random selectors are predicted without drawing", t, func() {
+		random := NewRandomSelector(nil, nil).SetSeed(42)
+		root := NewSelector(random)
+		for i := 0; i < 4; i++ {
+			root.AddChildNode(NewTerminal(NewA(root, nil, i)))
+		}
+		r := &leafRecorder{}
+		tree := NewBevTree(root).AddListener(r)
+
+		output := 0
+		for i := 0; i < 20; i++ {
+			leaf := tree.Plan(nil).Leaf()
+			So(tree.Plan(nil).Leaf(), ShouldEqual, leaf)
+			tree.Update(nil, &output)
+			So(r.ticked[i], ShouldEqual, leaf)
+		}
+
+		unseeded := NewSelector(NewRandomSelector(nil, nil))
+		unseeded.AddChildNode(NewTerminal(NewA(unseeded, nil, 1)))
+		plan := NewBevTree(unseeded).Plan(nil)
+		So(plan.Leaves, ShouldBeEmpty)
+		So(plan.Unpredictable, ShouldResemble, []IBevNode{unseeded})
+	})
+
+	Convey("Seeded weighted utility selectors are predicted", t, func() {
+		scoreA, scoreB, condA := 1.0, 2.0, true
+		root, _, _, u := newUtilityTree(&scoreA, &scoreB, &condA)
+		u.SetMode(UtilityWeightedRandom).SetSeed(7)
+		r := &leafRecorder{}
+		tree := NewBevTree(root).AddListener(r)
+
+		output := 0
+		picked := map[IBevNode]bool{}
+		for i := 0; i < 20; i++ {
+			leaf := tree.Plan(nil).Leaf()
+			So(leaf, ShouldNotBeNil)
+			tree.Update(nil, &output)
+			So(r.ticked[i], ShouldEqual, leaf)
+			picked[leaf] = true
+		}
+		So(picked, ShouldHaveLength, 2)
+	})
+
+	Convey("Parallel selectors tick all their children", t, func() {
+		root := NewSelector(NewParallelSelector(nil, nil))
+		a := NewTerminal(NewA(root, nil, 1))
+		b := NewTerminal(NewA(root, nil, 2))
+		root.AddChildNode(a)
+		root.AddChildNode(b)
+		So(NewBevTree(root).Plan(nil).Leaves, ShouldResemble, []IBevNode{a, b})
+	})
+}
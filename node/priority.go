@@ -43,7 +43,7 @@ func NewPrioritySelector(parentNode IBevNode, nodePrecondition p.IPrecondition)
 func (node *PrioritySelector) Evaluate(input interface{}) bool {
 	node.currentSelectIndex = ConstInvalidChildNodeIndex
 	for i := 0; i < node.childNodeCount; i++ {
-		if node.childNodeList[i].Evaluate(input) {
+		if node.evaluateChild(i, input) {
 			node.currentSelectIndex = i
 			return true
 		}
@@ -53,7 +53,7 @@ func (node *PrioritySelector) Evaluate(input interface{}) bool {
 
 func (node *PrioritySelector) Transition(input interface{}) {
 	if node.checkIndex(node.lastSelectIndex) {
-		node.childNodeList[node.lastSelectIndex].Transition(input)
+		node.transitionChild(node.lastSelectIndex, input)
 	}
 	node.lastSelectIndex = ConstInvalidChildNodeIndex
 }
@@ -64,16 +64,14 @@ func (node *PrioritySelector) Tick(input interface{}, output interface{}) BevRun
 	if node.checkIndex(node.currentSelectIndex) {
 		if node.lastSelectIndex != node.currentSelectIndex {
 			if node.checkIndex(node.lastSelectIndex) {
-				lastNode := node.childNodeList[node.lastSelectIndex]
-				lastNode.Transition(input)
+				node.transitionChild(node.lastSelectIndex, input)
 			}
 			node.lastSelectIndex = node.currentSelectIndex
 		}
 	}
 
 	if node.checkIndex(node.lastSelectIndex) {
-		curNode := node.childNodeList[node.lastSelectIndex]
-		isFinish = curNode.Tick(input, output)
+		isFinish = node.tickChild(node.lastSelectIndex, input, output)
 		if isFinish == StateFinish {
 			node.lastSelectIndex = ConstInvalidChildNodeIndex
 		}
@@ -81,3 +79,7 @@ func (node *PrioritySelector) Tick(input interface{}, output interface{}) BevRun
 
 	return isFinish
 }
+
+func (node *PrioritySelector) CloneNode() IBevNode {
+	return &PrioritySelector{NewBevNode(nil, nil), node.currentSelectIndex, node.lastSelectIndex}
+}
@@ -33,19 +33,70 @@ import (
  */
 type RandomSelector struct {
 	*PrioritySelector
+	seeded *seededRand
 }
 
 func NewRandomSelector(parentNode IBevNode, nodePrecondition p.IPrecondition) *RandomSelector {
-	return &RandomSelector{NewPrioritySelector(parentNode, nodePrecondition)}
+	return &RandomSelector{PrioritySelector: NewPrioritySelector(parentNode, nodePrecondition)}
+}
+
+// SetSeed makes the selector draw from its own source, which Plan can predict
+func (node *RandomSelector) SetSeed(seed int64) *RandomSelector {
+	node.seeded = newSeededRand(seed)
+	return node
 }
 
 func (node *RandomSelector) Evaluate(input interface{}) bool {
 	if node.childNodeCount >= 1 {
-		randomIndex := rand.Intn(node.childNodeCount)
-		if node.childNodeList[randomIndex].Evaluate(input) == true {
+		var randomIndex int
+		if node.seeded != nil {
+			randomIndex = node.seeded.intn(node.childNodeCount)
+		} else {
+			randomIndex = rand.Intn(node.childNodeCount)
+		}
+		if node.evaluateChild(randomIndex, input) == true {
 			node.currentSelectIndex = randomIndex
 			return true
 		}
 	}
 	return false
 }
+
+func (node *RandomSelector) CloneNode() IBevNode {
+	clone := &RandomSelector{PrioritySelector: node.PrioritySelector.CloneNode().(*PrioritySelector)}
+	if node.seeded != nil {
+		seeded := *node.seeded
+		clone.seeded = &seeded
+	}
+	return clone
+}
+
+/*
+ * seededRand is a splitmix64 generator, its whole state is one value so that
+ * Plan draws from a copy and leaves the source of the selector untouched
+ */
+type seededRand struct {
+	state uint64
+}
+
+func newSeededRand(seed int64) *seededRand {
+	return &seededRand{uint64(seed)}
+}
+
+func (r *seededRand) next() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// in [0, n)
+func (r *seededRand) intn(n int) int {
+	return int(r.next() % uint64(n))
+}
+
+// in [0, 1)
+func (r *seededRand) float64() float64 {
+	return float64(r.next()>>11) / (1 << 53)
+}
@@ -0,0 +1,194 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+/*
+ * RenderOptions
+ */
+type RenderOptions struct {
+	ASCII         bool // connectors made of "|", "`" and "-" instead of box drawing characters
+	Preconditions bool // show the precondition of every node
+	RunState      bool // show the last status, the active nodes and the selected child indices
+}
+
+type connectors struct {
+	branch, last, pipe, space string
+}
+
+var (
+	boxConnectors   = connectors{"├── ", "└── ", "│   ", "    "}
+	asciiConnectors = connectors{"|-- ", "`-- ", "|   ", "    "}
+)
+
+/*
+ * RenderTree writes one line per node, like
+ *
+ *	root (PrioritySelector) [executing] *
+ *	├── attack (SequenceSelector) <canAttack> [executing] index=1/2 *
+ *	│   ├── aim (AimNode) [finish]
+ *	│   └── shoot (ShootNode) [executing] *
+ *	└── idle (IdleNode)
+ *
+ * where "*" marks the nodes of ActivePath. Reversed nodes are prefixed with "!".
+ */
+func RenderTree(w io.Writer, root IBevNode, opts RenderOptions) error {
+	r := &renderer{w: w, opts: opts, conn: boxConnectors, visited: map[*BevNode]bool{}}
+	if opts.ASCII {
+		r.conn = asciiConnectors
+	}
+	if root == nil {
+		r.printf("<nil>\n")
+		return r.err
+	}
+	if opts.RunState {
+		r.active = map[*BevNode]bool{}
+		for _, n := range ActivePath(root) {
+			r.active[bevNodeOf(n)] = true
+		}
+	}
+	r.render(root, "", "")
+	return r.err
+}
+
+// SprintTree returns the rendering of RenderTree, handy for logs and test failures
+func SprintTree(root IBevNode, opts RenderOptions) string {
+	var buf bytes.Buffer
+	RenderTree(&buf, root, opts)
+	return buf.String()
+}
+
+type renderer struct {
+	w       io.Writer
+	opts    RenderOptions
+	conn    connectors
+	visited map[*BevNode]bool
+	active  map[*BevNode]bool
+	err     error
+}
+
+func (r *renderer) printf(format string, a ...interface{}) {
+	if r.err == nil {
+		_, r.err = fmt.Fprintf(r.w, format, a...)
+	}
+}
+
+func (r *renderer) render(node IBevNode, prefix string, childPrefix string) {
+	base := bevNodeOf(node)
+	if r.visited[base] {
+		r.printf("%s%s (already shown)\n", prefix, NodeLabel(node))
+		return
+	}
+	r.visited[base] = true
+
+	r.printf("%s%s\n", prefix, r.describe(node))
+	children := base.getChildNodes()
+	for i, child := range children {
+		conn, next := r.conn.branch, r.conn.pipe
+		if i == len(children)-1 {
+			conn, next = r.conn.last, r.conn.space
+		}
+		if child == nil {
+			r.printf("%s%s<nil>\n", childPrefix, conn)
+			continue
+		}
+		r.render(child, childPrefix+conn, childPrefix+next)
+	}
+}
+
+func (r *renderer) describe(node IBevNode) string {
+	base := bevNodeOf(node)
+	var b strings.Builder
+	if _, ok := node.(*BevReverse); ok {
+		b.WriteString("!")
+	}
+	b.WriteString(NodeLabel(node))
+	if typeName := NodeTypeName(node); typeName != NodeLabel(node) {
+		fmt.Fprintf(&b, " (%s)", typeName)
+	}
+	if r.opts.Preconditions && base.nodePrecondition != nil {
+		fmt.Fprintf(&b, " <%s>", DescribePrecondition(base.nodePrecondition))
+	}
+	if r.opts.RunState {
+		if base.hasLastStatus {
+			fmt.Fprintf(&b, " [%s]", base.lastStatus)
+		}
+		if index := selectedIndex(node); index != "" {
+			b.WriteString(" " + index)
+		}
+		if r.active[base] {
+			b.WriteString(" *")
+		}
+	}
+	return b.String()
+}
+
+// progress of the composites which remember a child
+func selectedIndex(node IBevNode) string {
+	switch n := unwrapNode(node).(type) {
+	case *SequenceSelector:
+		if n.checkIndex(n.currentSelectIndex) {
+			return fmt.Sprintf("index=%d/%d", n.currentSelectIndex, n.childNodeCount)
+		}
+	case *LoopSelector:
+		if n.loopCount == ConstInfiniteLoop {
+			return fmt.Sprintf("loop=%d/inf", n.currentCount)
+		}
+		return fmt.Sprintf("loop=%d/%d", n.currentCount, n.loopCount)
+	case *PrioritySelector:
+		return priorityIndex(n)
+	case *NonePrioritySelector:
+		return priorityIndex(n.PrioritySelector)
+	case *RandomSelector:
+		return priorityIndex(n.PrioritySelector)
+	case *UtilitySelector:
+		scores := make([]string, n.childNodeCount)
+		for i := range scores {
+			scores[i] = strconv.FormatFloat(n.scores[i], 'g', 3, 64)
+		}
+		return strings.TrimSpace(priorityIndex(n.PrioritySelector) + " scores=" + strings.Join(scores, ","))
+	}
+	return ""
+}
+
+func priorityIndex(n *PrioritySelector) string {
+	if n.checkIndex(n.lastSelectIndex) {
+		return fmt.Sprintf("select=%d", n.lastSelectIndex)
+	}
+	return ""
+}
+
+// DescribePrecondition uses String of cond when it has one, its type name otherwise
+func DescribePrecondition(cond p.IPrecondition) string {
+	return p.Describe(cond)
+}
@@ -0,0 +1,72 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRenderTree(t *testing.T) {
+	root := NewSelector(NewPrioritySelector(nil, nil))
+	root.SetDebugName("root")
+	seq := NewSelector(NewSequenceSelector(root, NewPreconditionTRUE()))
+	seq.SetDebugName("seq")
+	root.AddChildNode(seq)
+	seq.AddChildNode(NewTerminal(NewA(seq, nil, 1)))
+	wait := NewTerminal(&B{NewTerminalNode(seq, nil), 0})
+	wait.SetDebugName("wait")
+	seq.AddChildNode(wait)
+	root.AddChildNode(NewReverse(NewTerminal(NewA(root, NewPreconditionFALSE(), 2))))
+
+	tree := NewBevTree(root)
+	output := 0
+	tree.Update(nil, &output)
+	tree.Update(nil, &output)
+
+	Convey("Box drawing with run state", t, func() {
+		So(SprintTree(root, RenderOptions{Preconditions: true, RunState: true}), ShouldEqual, strings.Join([]string{
+			"root (PrioritySelector) [executing] select=0 *",
+			"├── seq (SequenceSelector) <true> [executing] index=1/2 *",
+			"│   ├── A [finish]",
+			"│   └── wait (B) [executing] *",
+			"└── !A <false>",
+			"",
+		}, "\n"))
+	})
+
+	Convey("Plain ascii", t, func() {
+		So(SprintTree(root, RenderOptions{ASCII: true}), ShouldEqual, strings.Join([]string{
+			"root (PrioritySelector)",
+			"|-- seq (SequenceSelector)",
+			"|   |-- A",
+			"|   `-- wait (B)",
+			"`-- !A",
+			"",
+		}, "\n"))
+	})
+}
@@ -45,14 +45,14 @@ func (node *SequenceSelector) Evaluate(input interface{}) bool {
 		Index = 0
 	}
 	if node.checkIndex(Index) {
-		return node.childNodeList[Index].Evaluate(input)
+		return node.evaluateChild(Index, input)
 	}
 	return false
 }
 
 func (node *SequenceSelector) Transition(input interface{}) {
 	if node.checkIndex(node.currentSelectIndex) {
-		node.childNodeList[node.currentSelectIndex].Transition(input)
+		node.transitionChild(node.currentSelectIndex, input)
 	}
 	node.currentSelectIndex = ConstInvalidChildNodeIndex
 }
@@ -65,7 +65,7 @@ func (node *SequenceSelector) Tick(input interface{}, output interface{}) BevRun
 	}
 
 	if node.checkIndex(node.currentSelectIndex) {
-		bIsFinish = node.childNodeList[node.currentSelectIndex].Tick(input, output)
+		bIsFinish = node.tickChild(node.currentSelectIndex, input, output)
 		if bIsFinish == StateFinish {
 			node.currentSelectIndex += 1
 			if node.currentSelectIndex >= node.childNodeCount {
@@ -82,3 +82,7 @@ func (node *SequenceSelector) Tick(input interface{}, output interface{}) BevRun
 
 	return bIsFinish
 }
+
+func (node *SequenceSelector) CloneNode() IBevNode {
+	return &SequenceSelector{NewBevNode(nil, nil), node.currentSelectIndex}
+}
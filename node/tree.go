@@ -0,0 +1,227 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"time"
+
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+/*
+ * BevTree owns a root node and the listeners attached to its nodes
+ */
+type BevTree struct {
+	root             IBevNode
+	listeners        []IBevListener
+	executeListeners []IBevExecuteListener
+	errorListeners   []IBevErrorListener
+	condListeners    []IBevPreconditionListener
+	logger           *treeLogger
+	cache            *p.Cache
+	frame            int
+	errorPolicy      ErrorPolicy
+	errors           []error
+	failedBranch     bool
+}
+
+func NewBevTree(root IBevNode) *BevTree {
+	tree := &BevTree{root: root}
+	if root != nil {
+		bevNodeOf(root).setTree(tree)
+	}
+	return tree
+}
+
+func (tree *BevTree) GetRoot() IBevNode {
+	return tree.root
+}
+
+// number of finished Update calls
+func (tree *BevTree) GetFrame() int {
+	return tree.frame
+}
+
+func (tree *BevTree) AddListener(l IBevListener) *BevTree {
+	tree.listeners = append(tree.listeners, l)
+	if el, ok := l.(IBevExecuteListener); ok {
+		tree.executeListeners = append(tree.executeListeners, el)
+	}
+	if el, ok := l.(IBevErrorListener); ok {
+		tree.errorListeners = append(tree.errorListeners, el)
+	}
+	if cl, ok := l.(IBevPreconditionListener); ok {
+		tree.condListeners = append(tree.condListeners, cl)
+	}
+	return tree
+}
+
+func (tree *BevTree) RemoveListener(l IBevListener) *BevTree {
+	for i, v := range tree.listeners {
+		if v == l {
+			tree.listeners = append(tree.listeners[:i:i], tree.listeners[i+1:]...)
+			break
+		}
+	}
+	for i, v := range tree.executeListeners {
+		if v.(IBevListener) == l {
+			tree.executeListeners = append(tree.executeListeners[:i:i], tree.executeListeners[i+1:]...)
+			break
+		}
+	}
+	for i, v := range tree.errorListeners {
+		if v.(IBevListener) == l {
+			tree.errorListeners = append(tree.errorListeners[:i:i], tree.errorListeners[i+1:]...)
+			break
+		}
+	}
+	for i, v := range tree.condListeners {
+		if v.(IBevListener) == l {
+			tree.condListeners = append(tree.condListeners[:i:i], tree.condListeners[i+1:]...)
+			break
+		}
+	}
+	return tree
+}
+
+/*
+ * SetPreconditionCache makes the wrappers of the tree evaluate node
+ * preconditions through cache, results are kept for one Update. nil disables it.
+ */
+func (tree *BevTree) SetPreconditionCache(cache *p.Cache) *BevTree {
+	tree.cache = cache
+	return tree
+}
+
+func (tree *BevTree) GetPreconditionCache() *p.Cache {
+	return tree.cache
+}
+
+/*
+ * Update runs one frame: tick the root if it evaluates true, otherwise
+ * transition it so running terminals exit, and StateTransition is returned.
+ * Precondition errors of the frame are returned by Err.
+ */
+func (tree *BevTree) Update(input interface{}, output interface{}) BevRunningStatus {
+	defer func() {
+		tree.frame++
+		tree.logger.setFrame(tree.frame)
+	}()
+	if tree.cache != nil {
+		tree.cache.SetFrame(tree.frame)
+	}
+	tree.errors = nil
+	tree.failedBranch = false
+
+	result := tree.root.Evaluate(input)
+	tree.failedBranch = false
+	tree.onEvaluate(tree.root, result)
+	if !result {
+		tree.root.Transition(input)
+		bevNodeOf(tree.root).setLastStatus(StateTransition)
+		tree.onTransition(tree.root)
+		return StateTransition
+	}
+
+	status := tree.root.Tick(input, output)
+	bevNodeOf(tree.root).setLastStatus(status)
+	tree.onTick(tree.root, status)
+	return status
+}
+
+/*
+ * Notifications, tree may be nil
+ */
+func (tree *BevTree) onEvaluate(node IBevNode, result bool) {
+	if tree == nil {
+		return
+	}
+	for _, l := range tree.listeners {
+		l.OnEvaluate(node, result)
+	}
+}
+
+func (tree *BevTree) onPrecondition(node IBevNode, result bool) {
+	if tree == nil {
+		return
+	}
+	for _, l := range tree.condListeners {
+		l.OnPrecondition(node, result)
+	}
+}
+
+// make node the current one while it runs user code, the previous one is returned
+func (tree *BevTree) setCurrent(node IBevNode) IBevNode {
+	if tree == nil || tree.logger == nil {
+		return nil
+	}
+	return tree.logger.setCurrent(node)
+}
+
+func (tree *BevTree) onEnter(node IBevNode) {
+	if tree == nil {
+		return
+	}
+	for _, l := range tree.listeners {
+		l.OnEnter(node)
+	}
+}
+
+func (tree *BevTree) onTick(node IBevNode, status BevRunningStatus) {
+	if tree == nil {
+		return
+	}
+	for _, l := range tree.listeners {
+		l.OnTick(node, status)
+	}
+}
+
+func (tree *BevTree) onExit(node IBevNode, status BevRunningStatus) {
+	if tree == nil {
+		return
+	}
+	for _, l := range tree.listeners {
+		l.OnExit(node, status)
+	}
+}
+
+func (tree *BevTree) onTransition(node IBevNode) {
+	if tree == nil {
+		return
+	}
+	for _, l := range tree.listeners {
+		l.OnTransition(node)
+	}
+}
+
+// whether Execute has to be timed
+func (tree *BevTree) timed() bool {
+	return tree != nil && len(tree.executeListeners) > 0
+}
+
+func (tree *BevTree) onExecute(node IBevNode, status BevRunningStatus, elapsed time.Duration) {
+	for _, l := range tree.executeListeners {
+		l.OnExecute(node, status, elapsed)
+	}
+}
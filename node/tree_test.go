@@ -0,0 +1,260 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	"github.com/ShionRyuu/gobevtree/precondition/expr"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type recorder struct {
+	BevListener
+	events []string
+}
+
+func (r *recorder) OnEvaluate(node IBevNode, result bool) {
+	r.events = append(r.events, fmt.Sprintf("evaluate %s %v", NodeLabel(node), result))
+}
+
+func (r *recorder) OnEnter(node IBevNode) {
+	r.events = append(r.events, "enter "+NodeLabel(node))
+}
+
+func (r *recorder) OnTick(node IBevNode, status BevRunningStatus) {
+	r.events = append(r.events, fmt.Sprintf("tick %s %d", NodeLabel(node), status))
+}
+
+func (r *recorder) OnExit(node IBevNode, status BevRunningStatus) {
+	r.events = append(r.events, fmt.Sprintf("exit %s %d", NodeLabel(node), status))
+}
+
+func (r *recorder) OnTransition(node IBevNode) {
+	r.events = append(r.events, "transition "+NodeLabel(node))
+}
+
+func TestListener(t *testing.T) {
+	Convey("Listeners see every node of the tree", t, func() {
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.SetDebugName("root")
+		wait := NewTerminal(&B{NewTerminalNode(root, NewPreconditionTRUE()), 0})
+		wait.SetDebugName("wait")
+		root.AddChildNode(wait)
+
+		tree := NewBevTree(root)
+		first, second := &recorder{}, &recorder{}
+		tree.AddListener(first).AddListener(second)
+
+		// added after the tree is created
+		idle := NewTerminal(NewA(root, nil, 1))
+		idle.SetDebugName("idle")
+		root.AddChildNode(idle)
+
+		output := 0
+		So(tree.Update(nil, &output), ShouldEqual, StateExecuting)
+		So(first.events, ShouldResemble, []string{
+			"evaluate wait true",
+			"evaluate root true",
+			"enter wait",
+			"tick wait 1",
+			"tick root 1",
+		})
+
+		tree.RemoveListener(second)
+		So(tree.Update(nil, &output), ShouldEqual, StateFinish)
+		So(tree.GetFrame(), ShouldEqual, 2)
+		So(len(second.events), ShouldEqual, 5)
+	})
+
+	Convey("Running terminals exit when the tree transitions", t, func() {
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.SetDebugName("root")
+		wait := NewTerminal(&B{NewTerminalNode(root, nil), 0})
+		wait.SetDebugName("wait")
+		root.AddChildNode(wait)
+
+		tree := NewBevTree(root)
+		r := &recorder{}
+		tree.AddListener(r)
+
+		output := 0
+		tree.Update(nil, &output)
+		root.SetNodePrecondition(NewPreconditionFALSE())
+		r.events = nil
+		So(tree.Update(nil, &output), ShouldEqual, StateTransition)
+		So(r.events, ShouldResemble, []string{
+			"evaluate root false",
+			"exit wait -1",
+			"transition wait",
+			"transition root",
+		})
+	})
+}
+
+func TestPreconditionCache(t *testing.T) {
+	Convey("A guard shared by several nodes is evaluated once per frame", t, func() {
+		run := func(cache *Cache) int {
+			guard := &counterCond{}
+			root := NewSelector(NewNonePrioritySelector(nil, guard))
+			root.AddChildNode(NewTerminal(&B{NewTerminalNode(root, guard), 0}))
+			root.AddChildNode(NewTerminal(NewA(root, guard, 1)))
+			tree := NewBevTree(root).SetPreconditionCache(cache)
+
+			output := 0
+			for i := 0; i < 3; i++ {
+				tree.Update(nil, &output)
+			}
+			return guard.count
+		}
+
+		So(run(nil), ShouldBeGreaterThan, 3)
+		cache := NewCache()
+		So(run(cache), ShouldEqual, 3)
+		So(cache.Frame(), ShouldEqual, 2)
+	})
+}
+
+type errorRecorder struct {
+	BevListener
+	errors []string
+}
+
+func (r *errorRecorder) OnError(node IBevNode, err error) {
+	r.errors = append(r.errors, NodeLabel(node)+": "+err.Error())
+}
+
+func TestErrorPolicy(t *testing.T) {
+	run := func(policy ErrorPolicy) (int, *BevTree, *errorRecorder) {
+		broken := Checked("target", func(input interface{}) (bool, error) {
+			return false, errors.New("no target")
+		})
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		branch := NewSelector(NewPrioritySelector(root, nil))
+		attack := NewTerminal(NewA(branch, broken, 1))
+		attack.SetDebugName("attack")
+		branch.AddChildNode(attack)
+		branch.AddChildNode(NewTerminal(NewA(branch, nil, 2)))
+		root.AddChildNode(branch)
+		root.AddChildNode(NewTerminal(NewA(root, nil, 3)))
+
+		r := &errorRecorder{}
+		tree := NewBevTree(root).SetErrorPolicy(policy).AddListener(r)
+		output := 0
+		tree.Update(nil, &output)
+		return output, tree, r
+	}
+
+	Convey("Errors are dropped by default", t, func() {
+		output, tree, r := run(ErrorAsFalse)
+		So(output, ShouldEqual, 2)
+		So(tree.Err(), ShouldBeNil)
+		So(r.errors, ShouldBeEmpty)
+	})
+
+	Convey("Reported errors reach the listeners and Err", t, func() {
+		output, tree, r := run(ErrorReport)
+		So(output, ShouldEqual, 2)
+		So(tree.Err().Error(), ShouldEqual, "precondition target: no target")
+		So(r.errors, ShouldResemble, []string{"attack: precondition target: no target"})
+	})
+
+	Convey("Errors of expression guards are reported", t, func() {
+		env := expr.NewEnv().Declare("hp", 1, expr.Int).Policies(PolicyError, PolicyError)
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		attack := NewTerminal(NewA(root, expr.MustCompile("hp < 30", env), 1))
+		attack.SetDebugName("attack")
+		root.AddChildNode(attack)
+		root.AddChildNode(NewTerminal(NewA(root, nil, 2)))
+
+		r := &errorRecorder{}
+		tree := NewBevTree(root).SetErrorPolicy(ErrorReport).AddListener(r)
+		output := 0
+		tree.Update(bb.NewBlackboard(), &output)
+		So(output, ShouldEqual, 2)
+		So(tree.Err().Error(), ShouldEqual, "precondition $1 < 30: key 1: Invalid Key")
+		So(r.errors, ShouldHaveLength, 1)
+	})
+
+	Convey("An error fails the parent even when a selector is evaluated next", t, func() {
+		broken := Checked("target", func(input interface{}) (bool, error) {
+			return false, errors.New("no target")
+		})
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.AddChildNode(NewTerminal(NewA(root, broken, 1)))
+		sibling := NewSelector(NewPrioritySelector(root, nil))
+		sibling.AddChildNode(NewTerminal(NewA(sibling, nil, 2)))
+		root.AddChildNode(sibling)
+		root.AddChildNode(NewTerminal(NewA(root, nil, 3)))
+
+		tree := NewBevTree(root).SetErrorPolicy(ErrorFailBranch)
+		output := 0
+		So(tree.Update(nil, &output), ShouldEqual, StateTransition)
+		So(output, ShouldEqual, 0)
+		So(tree.Err(), ShouldNotBeNil)
+
+		// without error the sibling selector is selected
+		tree.SetErrorPolicy(ErrorReport)
+		So(tree.Update(nil, &output), ShouldEqual, StateFinish)
+		So(output, ShouldEqual, 2)
+	})
+
+	Convey("An error fails the selector of the node", t, func() {
+		output, tree, r := run(ErrorFailBranch)
+		So(output, ShouldEqual, 3)
+		So(tree.Err(), ShouldNotBeNil)
+		So(r.errors, ShouldHaveLength, 1)
+		So(ErrorFailBranch.String(), ShouldEqual, "fail")
+	})
+}
+
+// implements IBevNode without embedding BevNode, like a node of another package
+type externalNode struct {
+	IBevNode
+}
+
+func TestExternalNode(t *testing.T) {
+	Convey("Nodes which do not embed BevNode are seen as leaves", t, func() {
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.SetDebugName("root")
+		leaf := NewTerminal(NewA(root, nil, 1))
+		leaf.SetDebugName("leaf")
+		root.AddChildNode(&externalNode{leaf})
+
+		output := 0
+		tree := NewBevTree(root)
+		So(tree.Update(nil, &output), ShouldEqual, StateFinish)
+		So(output, ShouldEqual, 1)
+		So(Validate(root), ShouldBeEmpty)
+		So(Explain(root, nil).Result, ShouldBeTrue)
+		var buf bytes.Buffer
+		So(RenderTree(&buf, root, RenderOptions{}), ShouldBeNil)
+		So(buf.String(), ShouldContainSubstring, "leaf")
+	})
+}
@@ -0,0 +1,252 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+// IScorer rates how useful a child of a UtilitySelector is, see package utility for curves
+type IScorer interface {
+	Score(input interface{}) float64
+}
+
+type ScorerFunc func(input interface{}) float64
+
+func (f ScorerFunc) Score(input interface{}) float64 {
+	return f(input)
+}
+
+/*
+ * UtilityMode
+ */
+const (
+	UtilityHighest        UtilityMode = iota // the best scored child whose precondition holds
+	UtilityWeightedRandom                    // a random child whose precondition holds, weighted by score
+)
+
+type UtilityMode int
+
+func (mode UtilityMode) String() string {
+	switch mode {
+	case UtilityHighest:
+		return "highest"
+	case UtilityWeightedRandom:
+		return "weighted"
+	}
+	return fmt.Sprintf("mode(%d)", int(mode))
+}
+
+/*
+ * UtilitySelector scores its children on every Evaluate and selects one whose
+ * precondition holds, the running child gets momentum added to its score so a
+ * slightly better child does not interrupt it. Children without a scorer score
+ * 0, a NaN score is treated as -Inf. It ticks and transitions the selected child
+ * like PrioritySelector.
+ */
+type UtilitySelector struct {
+	*PrioritySelector
+	scorers  [ConstMaxChildNodeCnt]IScorer
+	scores   [ConstMaxChildNodeCnt]float64
+	mode     UtilityMode
+	momentum float64
+	rand     *rand.Rand
+	seeded   *seededRand
+}
+
+func NewUtilitySelector(parentNode IBevNode, nodePrecondition p.IPrecondition) *UtilitySelector {
+	return &UtilitySelector{PrioritySelector: NewPrioritySelector(parentNode, nodePrecondition)}
+}
+
+// SetScorer sets the scorer of the index-th child
+func (node *UtilitySelector) SetScorer(index int, scorer IScorer) *UtilitySelector {
+	if index >= 0 && index < ConstMaxChildNodeCnt {
+		node.scorers[index] = scorer
+	}
+	return node
+}
+
+func (node *UtilitySelector) SetMode(mode UtilityMode) *UtilitySelector {
+	node.mode = mode
+	return node
+}
+
+// SetMomentum sets the bonus of the running child
+func (node *UtilitySelector) SetMomentum(momentum float64) *UtilitySelector {
+	node.momentum = momentum
+	return node
+}
+
+// SetRand sets the source of UtilityWeightedRandom, the global source of math/rand by default.
+// A rand.Rand is not safe for concurrent use, so clones draw from the global source until
+// SetRand is called on them.
+func (node *UtilitySelector) SetRand(r *rand.Rand) *UtilitySelector {
+	node.rand = r
+	node.seeded = nil
+	return node
+}
+
+// SetSeed makes UtilityWeightedRandom draw from its own source, which Plan can predict
+func (node *UtilitySelector) SetSeed(seed int64) *UtilitySelector {
+	node.rand = nil
+	node.seeded = newSeededRand(seed)
+	return node
+}
+
+// Scores returns the scores of the children computed by the last Evaluate, without momentum
+func (node *UtilitySelector) Scores() []float64 {
+	return append([]float64(nil), node.scores[:node.childNodeCount]...)
+}
+
+// score compared by the selection
+func (node *UtilitySelector) effectiveScore(index int) float64 {
+	return node.adjustScore(index, node.scores[index])
+}
+
+// score of the index-th child with momentum
+func (node *UtilitySelector) adjustScore(index int, score float64) float64 {
+	if math.IsNaN(score) {
+		return math.Inf(-1)
+	}
+	if index == node.lastSelectIndex {
+		score += node.momentum
+	}
+	return score
+}
+
+func (node *UtilitySelector) Evaluate(input interface{}) bool {
+	node.currentSelectIndex = ConstInvalidChildNodeIndex
+	order := make([]int, node.childNodeCount)
+	for i := 0; i < node.childNodeCount; i++ {
+		node.scores[i] = node.score(i, input)
+		order[i] = i
+	}
+
+	if node.mode == UtilityWeightedRandom {
+		return node.evaluateWeighted(input)
+	}
+
+	// best first, children are only evaluated until one holds
+	sort.SliceStable(order, func(a, b int) bool {
+		return node.effectiveScore(order[a]) > node.effectiveScore(order[b])
+	})
+	for _, i := range order {
+		if node.evaluateChild(i, input) {
+			node.currentSelectIndex = i
+			return true
+		}
+	}
+	return false
+}
+
+// score of the scorer of the index-th child, 0 without one
+func (node *UtilitySelector) score(index int, input interface{}) float64 {
+	if node.scorers[index] == nil {
+		return 0
+	}
+	return node.scorers[index].Score(input)
+}
+
+func (node *UtilitySelector) evaluateWeighted(input interface{}) bool {
+	var candidates []int
+	var weights []float64
+	total := 0.0
+	for i := 0; i < node.childNodeCount; i++ {
+		if node.evaluateChild(i, input) {
+			weight := math.Max(node.effectiveScore(i), 0)
+			candidates = append(candidates, i)
+			weights = append(weights, weight)
+			total += weight
+		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+
+	draw := 0.0
+	if total > 0 {
+		switch {
+		case node.seeded != nil:
+			draw = node.seeded.float64()
+		case node.rand != nil:
+			draw = node.rand.Float64()
+		default:
+			draw = rand.Float64()
+		}
+	}
+	node.currentSelectIndex = weightedPick(candidates, weights, draw*total)
+	return true
+}
+
+// the candidate at r in the cumulated weights, the first one when they are all 0
+func weightedPick(candidates []int, weights []float64, r float64) int {
+	picked := candidates[0]
+	for k, weight := range weights {
+		if weight > 0 {
+			// the last positive one when rounding makes r exceed the sum
+			picked = candidates[k]
+			if r < weight {
+				break
+			}
+		}
+		r -= weight
+	}
+	return picked
+}
+
+// CloneNode copies the seeded source but not the one of SetRand, see SetRand
+func (node *UtilitySelector) CloneNode() IBevNode {
+	clone := &UtilitySelector{
+		PrioritySelector: node.PrioritySelector.CloneNode().(*PrioritySelector),
+		scorers:          node.scorers,
+		scores:           node.scores,
+		mode:             node.mode,
+		momentum:         node.momentum,
+	}
+	if node.seeded != nil {
+		seeded := *node.seeded
+		clone.seeded = &seeded
+	}
+	return clone
+}
+
+// ChildScores maps the children of node to their scores when it is a UtilitySelector
+func ChildScores(node IBevNode) (map[IBevNode]float64, bool) {
+	u, ok := unwrapNode(node).(*UtilitySelector)
+	if !ok {
+		return nil, false
+	}
+	scores := map[IBevNode]float64{}
+	for i, child := range u.getChildNodes() {
+		if child != nil {
+			scores[child] = u.scores[i]
+		}
+	}
+	return scores, true
+}
@@ -0,0 +1,144 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func constScore(score *float64) ScorerFunc {
+	return func(input interface{}) float64 {
+		return *score
+	}
+}
+
+func newUtilityTree(scoreA *float64, scoreB *float64, condA *bool) (*BevSelector, *BevTerminal, *BevTerminal, *UtilitySelector) {
+	u := NewUtilitySelector(nil, nil)
+	root := NewSelector(u)
+	root.SetDebugName("root")
+	a := NewTerminal(&B{NewTerminalNode(root, flagCond{condA}), 0})
+	a.SetDebugName("a")
+	b := NewTerminal(&B{NewTerminalNode(root, nil), 0})
+	b.SetDebugName("b")
+	root.AddChildNode(a)
+	root.AddChildNode(b)
+	u.SetScorer(0, constScore(scoreA)).SetScorer(1, constScore(scoreB))
+	return root, a, b, u
+}
+
+func TestUtilitySelector(t *testing.T) {
+	Convey("Highest mode selects the best child whose precondition holds", t, func() {
+		scoreA, scoreB, condA := 0.2, 0.8, true
+		root, a, b, u := newUtilityTree(&scoreA, &scoreB, &condA)
+		tree := NewBevTree(root)
+
+		output := 0
+		tree.Update(nil, &output)
+		So(ActiveLeaf(root), ShouldEqual, b)
+		So(u.Scores(), ShouldResemble, []float64{0.2, 0.8})
+		scores, ok := ChildScores(root)
+		So(ok, ShouldBeTrue)
+		So(scores[a], ShouldEqual, 0.2)
+		So(scores[b], ShouldEqual, 0.8)
+
+		tree.Update(nil, &output)
+		scoreA, scoreB = 0.9, math.NaN()
+		condA = false
+		tree.Update(nil, &output)
+		So(ActiveLeaf(root), ShouldEqual, b)
+		condA = true
+		tree.Update(nil, &output)
+		So(ActiveLeaf(root), ShouldEqual, a)
+		So(SprintTree(root, RenderOptions{RunState: true}), ShouldContainSubstring, "select=0 scores=0.9,NaN")
+
+		_, ok = ChildScores(NewSelector(NewPrioritySelector(nil, nil)))
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Momentum keeps the running child until another is clearly better", t, func() {
+		scoreA, scoreB, condA := 0.5, 0.4, true
+		root, a, b, u := newUtilityTree(&scoreA, &scoreB, &condA)
+		u.SetMomentum(0.2)
+		tree := NewBevTree(root)
+
+		output := 0
+		tree.Update(nil, &output)
+		So(ActiveLeaf(root), ShouldEqual, a)
+		scoreB = 0.6
+		tree.Update(nil, &output)
+		So(output, ShouldEqual, 2)
+		So(ActiveLeaf(root), ShouldBeNil)
+
+		tree.Update(nil, &output)
+		So(ActiveLeaf(root), ShouldEqual, b)
+		scoreB = 0.45
+		tree.Update(nil, &output)
+		So(output, ShouldEqual, 2)
+	})
+
+	Convey("Weighted mode samples children by score", t, func() {
+		scoreA, scoreB, condA := 1.0, 3.0, true
+		root, a, b, u := newUtilityTree(&scoreA, &scoreB, &condA)
+		u.SetMode(UtilityWeightedRandom).SetRand(rand.New(rand.NewSource(1)))
+		So(u.mode.String(), ShouldEqual, "weighted")
+
+		picks := map[IBevNode]int{}
+		for i := 0; i < 400; i++ {
+			So(root.Evaluate(nil), ShouldBeTrue)
+			picks[u.getChildNodes()[u.currentSelectIndex]]++
+		}
+		So(picks[a], ShouldBeBetween, 60, 140)
+		So(picks[b], ShouldBeBetween, 260, 340)
+
+		scoreA, scoreB = 0, -1
+		So(root.Evaluate(nil), ShouldBeTrue)
+		So(u.currentSelectIndex, ShouldEqual, 0)
+		condA = false
+		scoreB = 0
+		So(root.Evaluate(nil), ShouldBeTrue)
+		So(u.currentSelectIndex, ShouldEqual, 1)
+
+		clone := u.CloneNode().(*UtilitySelector)
+		So(clone.rand, ShouldBeNil)
+		So(clone.mode, ShouldEqual, UtilityWeightedRandom)
+	})
+
+	Convey("Children without scorer are reported", t, func() {
+		u := NewUtilitySelector(nil, nil)
+		root := NewSelector(u)
+		root.SetDebugName("root")
+		child := NewTerminal(NewA(root, nil, 1))
+		child.SetDebugName("idle")
+		root.AddChildNode(child)
+		issues := Validate(root)
+		So(issues, ShouldHaveLength, 1)
+		So(issues[0].Severity, ShouldEqual, SeverityInfo)
+		So(strings.HasPrefix(issues[0].Path, "root/idle"), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,158 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"fmt"
+)
+
+/*
+ * IssueSeverity
+ */
+const (
+	SeverityInfo IssueSeverity = iota
+	SeverityWarning
+	SeverityError
+)
+
+type IssueSeverity int
+
+func (s IssueSeverity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+/*
+ * Issue found by Validate
+ */
+type Issue struct {
+	Severity IssueSeverity
+	Path     string
+	Message  string
+}
+
+func (issue Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", issue.Severity, issue.Path, issue.Message)
+}
+
+// Validate reports the mistakes which make a tree misbehave silently
+func Validate(root IBevNode) []Issue {
+	v := &validator{owners: map[*BevNode]string{}}
+	if root == nil {
+		v.report(SeverityError, "", "tree is nil")
+		return v.issues
+	}
+
+	path := NodeLabel(root)
+	if bevNodeOf(root).parentNode != nil {
+		v.report(SeverityWarning, path, "root has a parent node, active node is propagated outside of the tree")
+	}
+	v.validate(root, path)
+	return v.issues
+}
+
+type validator struct {
+	issues []Issue
+	owners map[*BevNode]string
+}
+
+func (v *validator) report(severity IssueSeverity, path string, format string, a ...interface{}) {
+	v.issues = append(v.issues, Issue{severity, path, fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) validate(node IBevNode, path string) {
+	base := bevNodeOf(node)
+	if owner, ok := v.owners[base]; ok {
+		v.report(SeverityError, path, "node is already used at %s, run state is shared", owner)
+		return
+	}
+	v.owners[base] = path
+
+	inner := unwrapNode(node)
+	_, isWrappedTerminal := node.(*BevTerminal)
+	if reverse, ok := node.(*BevReverse); ok {
+		_, isWrappedTerminal = reverse.IBevNode.(*BevTerminal)
+	}
+	_, isTerminal := inner.(IBevTerminal)
+
+	switch {
+	case isTerminal && !isWrappedTerminal:
+		v.report(SeverityError, path, "terminal is not wrapped with NewTerminal, it will never be executed")
+	case !isTerminal && !isSelector(node) && base.nodePrecondition != nil:
+		v.report(SeverityWarning, path, "selector is not wrapped with NewSelector, its precondition is ignored")
+	}
+
+	switch inner.(type) {
+	case *SequenceSelector, *LoopSelector:
+		if base.childNodeCount == 0 {
+			v.report(SeverityWarning, path, "%s has no children, it never evaluates true", NodeTypeName(inner))
+		}
+	}
+	if _, ok := inner.(*LoopSelector); ok && base.childNodeCount > 1 {
+		v.report(SeverityInfo, path, "LoopSelector only ticks its first child, %d children", base.childNodeCount)
+	}
+	if u, ok := inner.(*UtilitySelector); ok {
+		for i, child := range base.getChildNodes() {
+			if child != nil && u.scorers[i] == nil {
+				v.report(SeverityInfo, path+"/"+childSegment(child, i), "child of UtilitySelector has no scorer, it scores 0")
+			}
+		}
+	}
+	if isTerminal && base.childNodeCount > 0 {
+		v.report(SeverityWarning, path, "terminal has %d children, they are never ticked", base.childNodeCount)
+	}
+
+	for i, child := range base.getChildNodes() {
+		if child == nil {
+			v.report(SeverityError, fmt.Sprintf("%s/[%d]", path, i), "child is nil")
+			continue
+		}
+
+		childPath := path + "/" + childSegment(child, i)
+		parent := bevNodeOf(child).parentNode
+		if _, ok := child.(bevNodeHolder); !ok {
+			// the parent of a node which does not embed BevNode is unknown
+		} else if parent == nil {
+			v.report(SeverityWarning, childPath, "parent node is nil, active node is not propagated to %s", path)
+		} else if bevNodeOf(parent) != base {
+			v.report(SeverityWarning, childPath, "parent node is %s, not %s", NodeLabel(parent), path)
+		}
+		v.validate(child, childPath)
+	}
+}
+
+func isSelector(node IBevNode) bool {
+	if reverse, ok := node.(*BevReverse); ok {
+		node = reverse.IBevNode
+	}
+	_, ok := node.(*BevSelector)
+	return ok
+}
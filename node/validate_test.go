@@ -0,0 +1,64 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"testing"
+
+	. "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	Convey("A correctly wired tree has no issues", t, func() {
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		seq := NewSelector(NewSequenceSelector(root, NewPreconditionTRUE()))
+		root.AddChildNode(seq)
+		seq.AddChildNode(NewTerminal(NewA(seq, nil, 1)))
+		seq.AddChildNode(NewTerminal(NewA(seq, nil, 2)))
+		So(Validate(root), ShouldBeEmpty)
+	})
+
+	Convey("Common mistakes are reported with paths and severities", t, func() {
+		root := NewSelector(NewPrioritySelector(nil, nil))
+		root.SetDebugName("root")
+		shared := NewTerminal(NewA(root, nil, 1))
+		shared.SetDebugName("shared")
+		root.AddChildNode(shared)
+		root.AddChildNode(shared)
+		root.AddChildNode(nil)
+		root.AddChildNode(NewSelector(NewSequenceSelector(root, nil)))
+		root.AddChildNode(NewTerminal(NewA(nil, nil, 1)))
+		root.AddChildNode(NewA(root, NewPreconditionTRUE(), 1))
+
+		issues := Validate(root)
+		So(issues, ShouldResemble, []Issue{
+			{SeverityError, "root/shared", "node is already used at root/shared, run state is shared"},
+			{SeverityError, "root/[2]", "child is nil"},
+			{SeverityWarning, "root/SequenceSelector[3]", "SequenceSelector has no children, it never evaluates true"},
+			{SeverityWarning, "root/A[4]", "parent node is nil, active node is not propagated to root"},
+			{SeverityError, "root/A[5]", "terminal is not wrapped with NewTerminal, it will never be executed"},
+		})
+	})
+}
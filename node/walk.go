@@ -0,0 +1,104 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package node
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// strip the NewSelector, NewTerminal and NewReverse wrappers
+func unwrapNode(node IBevNode) IBevNode {
+	for {
+		switch w := node.(type) {
+		case *BevSelector:
+			node = w.IBevSelector
+		case *BevTerminal:
+			node = w.IBevTerminal
+		case *BevReverse:
+			node = w.IBevNode
+		default:
+			return node
+		}
+	}
+}
+
+// type name of the wrapped node, eg. "SequenceSelector"
+func NodeTypeName(node IBevNode) string {
+	t := reflect.TypeOf(unwrapNode(node))
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// debug name if set, type name otherwise
+func NodeLabel(node IBevNode) string {
+	if name := node.GetDebugName(); name != "" {
+		return name
+	}
+	return NodeTypeName(node)
+}
+
+// path segment of the index-th child, unnamed nodes are told apart by index
+func childSegment(child IBevNode, index int) string {
+	if name := child.GetDebugName(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s[%d]", NodeTypeName(child), index)
+}
+
+type WalkFunc func(node IBevNode, path string, depth int)
+
+// Walk visits root and its descendants depth-first, nil and already visited children are skipped
+func Walk(root IBevNode, visit WalkFunc) {
+	if root == nil {
+		return
+	}
+	walk(root, NodeLabel(root), 0, map[*BevNode]bool{}, visit)
+}
+
+func walk(node IBevNode, path string, depth int, visited map[*BevNode]bool, visit WalkFunc) {
+	base := bevNodeOf(node)
+	if visited[base] {
+		return
+	}
+	visited[base] = true
+
+	visit(node, path, depth)
+	for i, child := range base.getChildNodes() {
+		if child != nil {
+			walk(child, path+"/"+childSegment(child, i), depth+1, visited, visit)
+		}
+	}
+}
+
+// Paths maps every node reachable from root, as added to its parent, to its path
+func Paths(root IBevNode) map[IBevNode]string {
+	paths := map[IBevNode]string{}
+	Walk(root, func(node IBevNode, path string, depth int) {
+		paths[node] = path
+	})
+	return paths
+}
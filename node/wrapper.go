@@ -23,6 +23,10 @@
 
 package node
 
+import (
+	"time"
+)
+
 /*
  * Wrapper for Selector
  * https://groups.google.com/d/msg/golang-nuts/BKztgPqN87M/iUfZQIcNYfYJ
@@ -39,9 +43,23 @@ func NewSelector(node IBevSelector) *BevSelector {
 	return &BevSelector{node}
 }
 
+func (w *BevSelector) getBevNode() *BevNode {
+	return bevNodeOf(w.IBevSelector)
+}
+
 func (w *BevSelector) Evaluate(input interface{}) bool {
 	nodePrecondition := w.IBevSelector.GetNodePrecondition()
-	return (nodePrecondition == nil || nodePrecondition.ExternalCondition(input)) && w.IBevSelector.Evaluate(input)
+	if nodePrecondition != nil && !bevNodeOf(w).evaluatePrecondition(w, nodePrecondition, input) {
+		return false
+	}
+	tree := bevNodeOf(w).tree
+	siblings := tree.enterBranch()
+	result := w.IBevSelector.Evaluate(input)
+	// a precondition error of a child under ErrorFailBranch
+	if tree.leaveBranch(siblings) {
+		return false
+	}
+	return result
 }
 
 /*
@@ -64,14 +82,29 @@ func NewTerminal(node IBevTerminal) *BevTerminal {
 	return &BevTerminal{node, NodeReady, false}
 }
 
+func (w *BevTerminal) getBevNode() *BevNode {
+	return bevNodeOf(w.IBevTerminal)
+}
+
 func (w *BevTerminal) Evaluate(input interface{}) bool {
 	nodePrecondition := w.IBevTerminal.GetNodePrecondition()
-	return (nodePrecondition == nil || nodePrecondition.ExternalCondition(input)) && w.IBevTerminal.Evaluate(input)
+	if nodePrecondition != nil && !bevNodeOf(w).evaluatePrecondition(w, nodePrecondition, input) {
+		return false
+	}
+	tree := bevNodeOf(w).tree
+	prev := tree.setCurrent(w)
+	result := w.IBevTerminal.Evaluate(input)
+	tree.setCurrent(prev)
+	return result
 }
 
 func (node *BevTerminal) Transition(input interface{}) {
 	if node.needExit {
+		tree := bevNodeOf(node).tree
+		prev := tree.setCurrent(node)
 		node.Exit(input, StateTransition)
+		tree.setCurrent(prev)
+		tree.onExit(node, StateTransition)
 	}
 
 	node.SetActiveNode(nil)
@@ -82,15 +115,26 @@ func (node *BevTerminal) Transition(input interface{}) {
 func (node *BevTerminal) Tick(input interface{}, output interface{}) BevRunningStatus {
 	var bIsFinish BevRunningStatus = StateFinish
 
+	tree := bevNodeOf(node).tree
+	prev := tree.setCurrent(node)
+	defer tree.setCurrent(prev)
+
 	if node.nodeStatus == NodeReady {
 		node.Enter(input)
+		tree.onEnter(node)
 		node.needExit = true
 		node.nodeStatus = NodeRunning
 		node.SetActiveNode(node)
 	}
 
 	if node.nodeStatus == NodeRunning {
-		bIsFinish = node.Execute(input, output)
+		if tree.timed() {
+			start := time.Now()
+			bIsFinish = node.Execute(input, output)
+			tree.onExecute(node, bIsFinish, time.Since(start))
+		} else {
+			bIsFinish = node.Execute(input, output)
+		}
 		node.SetActiveNode(node)
 		if bIsFinish == StateFinish || bIsFinish < 0 {
 			node.nodeStatus = NodeFinish
@@ -100,6 +144,7 @@ func (node *BevTerminal) Tick(input interface{}, output interface{}) BevRunningS
 	if node.nodeStatus == NodeFinish {
 		if node.needExit {
 			node.Exit(input, bIsFinish)
+			tree.onExit(node, bIsFinish)
 		}
 
 		node.nodeStatus = NodeReady
@@ -124,3 +169,7 @@ func NewReverse(node IBevNode) *BevReverse {
 func (w *BevReverse) Evaluate(input interface{}) bool {
 	return !w.IBevNode.Evaluate(input)
 }
+
+func (w *BevReverse) getBevNode() *BevNode {
+	return bevNodeOf(w.IBevNode)
+}
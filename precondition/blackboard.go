@@ -0,0 +1,360 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package precondition
+
+import (
+	"fmt"
+	"math"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+)
+
+/*
+ * Operator of the blackboard comparisons
+ */
+const (
+	OpLess Operator = iota
+	OpLessEqual
+	OpEqual
+	OpNotEqual
+	OpGreaterEqual
+	OpGreater
+)
+
+type Operator int
+
+var operatorNames = [...]string{"<", "<=", "==", "!=", ">=", ">"}
+
+func (op Operator) String() string {
+	if op >= 0 && int(op) < len(operatorNames) {
+		return operatorNames[op]
+	}
+	return fmt.Sprintf("operator(%d)", int(op))
+}
+
+// ParseOperator returns the Operator written as "<", "<=", "==", "!=", ">=" or ">"
+func ParseOperator(s string) (Operator, error) {
+	for i, name := range operatorNames {
+		if name == s {
+			return Operator(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown operator %q", s)
+}
+
+// Apply returns a op b, a and b must both be ints, float64s, strings or bools
+func (op Operator) Apply(a, b interface{}) (bool, error) {
+	switch a.(type) {
+	case int, float64, string, bool:
+		if fmt.Sprintf("%T", a) == fmt.Sprintf("%T", b) {
+			return op.holds(compareValues(a, b)), nil
+		}
+	}
+	return false, fmt.Errorf("can not compare %T with %T", a, b)
+}
+
+// whether a comparison of a with b, -1, 0, 1 or unordered, satisfies op
+func (op Operator) holds(cmp int) bool {
+	if cmp == unordered {
+		return op == OpNotEqual
+	}
+	switch op {
+	case OpLess:
+		return cmp < 0
+	case OpLessEqual:
+		return cmp <= 0
+	case OpEqual:
+		return cmp == 0
+	case OpNotEqual:
+		return cmp != 0
+	case OpGreaterEqual:
+		return cmp >= 0
+	case OpGreater:
+		return cmp > 0
+	}
+	return false
+}
+
+/*
+ * KeyPolicy is the result of a blackboard precondition when a key is missing
+ * or holds a value of another type, or when the input is not a blackboard.
+ * PolicyError returns false and makes Check report a PreconditionError.
+ */
+const (
+	PolicyFalse KeyPolicy = iota
+	PolicyTrue
+	PolicyPanic
+	PolicyError
+)
+
+type KeyPolicy int
+
+func (policy KeyPolicy) String() string {
+	switch policy {
+	case PolicyFalse:
+		return "false"
+	case PolicyTrue:
+		return "true"
+	case PolicyPanic:
+		return "panic"
+	case PolicyError:
+		return "error"
+	}
+	return fmt.Sprintf("policy(%d)", int(policy))
+}
+
+// the types of value compared by PreconditionBoard
+type valueKind int
+
+const (
+	kindAny valueKind = iota
+	kindInt
+	kindFloat
+	kindString
+	kindBool
+)
+
+type boardTest int
+
+const (
+	testCompare boardTest = iota
+	testRange
+	testExists
+	testNil
+)
+
+/*
+ * PreconditionBoard tests a blackboard value: compares it with a constant or
+ * with the value of another key, checks it is in a range, is set or is nil.
+ * Both policies are PolicyFalse unless changed with OnMissing and OnWrongType.
+ */
+type PreconditionBoard struct {
+	test        boardTest
+	kind        valueKind
+	key         int
+	op          Operator
+	operand     interface{} // constant, or lower bound of a range
+	max         interface{} // upper bound of a range
+	otherKey    int
+	keyOperand  bool // compare with the value of otherKey instead of operand
+	onMissing   KeyPolicy
+	onWrongType KeyPolicy
+}
+
+// compare the int of key with value
+func CompareInt(key int, op Operator, value int) *PreconditionBoard {
+	return &PreconditionBoard{kind: kindInt, key: key, op: op, operand: value}
+}
+
+// compare the ints of key and other
+func CompareIntKeys(key int, op Operator, other int) *PreconditionBoard {
+	return &PreconditionBoard{kind: kindInt, key: key, op: op, otherKey: other, keyOperand: true}
+}
+
+// compare the float of key with value, float32 and float64 values are accepted
+func CompareFloat(key int, op Operator, value float64) *PreconditionBoard {
+	return &PreconditionBoard{kind: kindFloat, key: key, op: op, operand: value}
+}
+
+func CompareFloatKeys(key int, op Operator, other int) *PreconditionBoard {
+	return &PreconditionBoard{kind: kindFloat, key: key, op: op, otherKey: other, keyOperand: true}
+}
+
+func CompareString(key int, op Operator, value string) *PreconditionBoard {
+	return &PreconditionBoard{kind: kindString, key: key, op: op, operand: value}
+}
+
+func CompareStringKeys(key int, op Operator, other int) *PreconditionBoard {
+	return &PreconditionBoard{kind: kindString, key: key, op: op, otherKey: other, keyOperand: true}
+}
+
+// compare the bool of key with value, false is less than true
+func CompareBool(key int, op Operator, value bool) *PreconditionBoard {
+	return &PreconditionBoard{kind: kindBool, key: key, op: op, operand: value}
+}
+
+func CompareBoolKeys(key int, op Operator, other int) *PreconditionBoard {
+	return &PreconditionBoard{kind: kindBool, key: key, op: op, otherKey: other, keyOperand: true}
+}
+
+// min <= the int of key <= max
+func InRangeInt(key int, min int, max int) *PreconditionBoard {
+	return &PreconditionBoard{test: testRange, kind: kindInt, key: key, operand: min, max: max}
+}
+
+// min <= the float of key <= max
+func InRangeFloat(key int, min float64, max float64) *PreconditionBoard {
+	return &PreconditionBoard{test: testRange, kind: kindFloat, key: key, operand: min, max: max}
+}
+
+// key is set, whatever its value, OnMissing has no effect
+func KeyExists(key int) *PreconditionBoard {
+	return &PreconditionBoard{test: testExists, key: key}
+}
+
+// key is set to nil
+func KeyIsNil(key int) *PreconditionBoard {
+	return &PreconditionBoard{test: testNil, key: key}
+}
+
+// OnMissing sets the result when a key is not on the blackboard
+func (Cond *PreconditionBoard) OnMissing(policy KeyPolicy) *PreconditionBoard {
+	Cond.onMissing = policy
+	return Cond
+}
+
+// OnWrongType sets the result when a value has another type or input is not a blackboard
+func (Cond *PreconditionBoard) OnWrongType(policy KeyPolicy) *PreconditionBoard {
+	Cond.onWrongType = policy
+	return Cond
+}
+
+func (Cond *PreconditionBoard) ExternalCondition(input interface{}) bool {
+	result, _ := Cond.Check(input)
+	return result
+}
+
+func (Cond *PreconditionBoard) Check(input interface{}) (bool, error) {
+	board, ok := input.(*bb.BlackBoard)
+	if !ok {
+		return Cond.fail(Cond.onWrongType, fmt.Errorf("%w, got %T", ErrNotBlackboard, input))
+	}
+
+	if Cond.test == testExists {
+		_, err := board.GetValueAsInterface(Cond.key)
+		return err == nil, nil
+	}
+	value, err := Cond.read(board, Cond.key)
+	if err != nil {
+		return Cond.failKey(Cond.key, err)
+	}
+
+	switch Cond.test {
+	case testNil:
+		return value == nil, nil
+	case testRange:
+		low, high := compareValues(value, Cond.operand), compareValues(value, Cond.max)
+		return low != unordered && high != unordered && low >= 0 && high <= 0, nil
+	}
+
+	operand := Cond.operand
+	if Cond.keyOperand {
+		if operand, err = Cond.read(board, Cond.otherKey); err != nil {
+			return Cond.failKey(Cond.otherKey, err)
+		}
+	}
+	return Cond.op.holds(compareValues(value, operand)), nil
+}
+
+func (Cond *PreconditionBoard) read(board *bb.BlackBoard, key int) (interface{}, error) {
+	switch Cond.kind {
+	case kindInt:
+		return board.GetValueAsInt(key)
+	case kindFloat:
+		v, err := board.GetValueAsInterface(key)
+		switch f := v.(type) {
+		case float64:
+			return f, nil
+		case float32:
+			return float64(f), nil
+		}
+		if err == nil {
+			err = bb.ErrInvalidType
+		}
+		return nil, err
+	case kindString:
+		return board.GetValueAsString(key)
+	case kindBool:
+		return board.GetValueAsBool(key)
+	}
+	return board.GetValueAsInterface(key)
+}
+
+func (Cond *PreconditionBoard) failKey(key int, err error) (bool, error) {
+	policy := Cond.onWrongType
+	if err == bb.ErrInvalidKey {
+		policy = Cond.onMissing
+	}
+	return Cond.fail(policy, fmt.Errorf("key %d: %w", key, err))
+}
+
+func (Cond *PreconditionBoard) fail(policy KeyPolicy, err error) (bool, error) {
+	switch policy {
+	case PolicyPanic:
+		panic(&PreconditionError{Cond, err})
+	case PolicyError:
+		return false, &PreconditionError{Cond, err}
+	}
+	return policy == PolicyTrue, nil
+}
+
+// result of compareValues when a float is NaN, only != holds
+const unordered = 2
+
+// -1, 0, 1 or unordered, a and b are values of the same kind
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int:
+		return compareOrdered(a < b.(int), a > b.(int))
+	case float64:
+		if math.IsNaN(a) || math.IsNaN(b.(float64)) {
+			return unordered
+		}
+		return compareOrdered(a < b.(float64), a > b.(float64))
+	case string:
+		return compareOrdered(a < b.(string), a > b.(string))
+	case bool:
+		return compareOrdered(!a && b.(bool), a && !b.(bool))
+	}
+	return 0
+}
+
+func compareOrdered(less, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+// keys are written $key, eg. "$1 < $2", "$1 in [0, 10]"
+func (Cond *PreconditionBoard) String() string {
+	switch Cond.test {
+	case testRange:
+		return fmt.Sprintf("$%d in [%v, %v]", Cond.key, Cond.operand, Cond.max)
+	case testExists:
+		return fmt.Sprintf("exists($%d)", Cond.key)
+	case testNil:
+		return fmt.Sprintf("$%d == nil", Cond.key)
+	}
+	if Cond.keyOperand {
+		return fmt.Sprintf("$%d %s $%d", Cond.key, Cond.op, Cond.otherKey)
+	}
+	if s, ok := Cond.operand.(string); ok {
+		return fmt.Sprintf("$%d %s %q", Cond.key, Cond.op, s)
+	}
+	return fmt.Sprintf("$%d %s %v", Cond.key, Cond.op, Cond.operand)
+}
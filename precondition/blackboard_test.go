@@ -0,0 +1,159 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package precondition
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBoardPreconditions(t *testing.T) {
+	board := bb.NewBlackboard()
+	board.SetValueAsInt(1, 5)
+	board.SetValueAsInt(2, 10)
+	board.SetValueAsFloat32(3, 0.5)
+	board.SetValueAsFloat64(4, 2.5)
+	board.SetValueAsString(5, "idle")
+	board.SetValueAsBool(6, true)
+	board.SetValueAsInterface(7, nil)
+
+	Convey("Values are compared with constants", t, func() {
+		So(CompareInt(1, OpLess, 10).ExternalCondition(board), ShouldBeTrue)
+		So(CompareInt(1, OpLessEqual, 5).ExternalCondition(board), ShouldBeTrue)
+		So(CompareInt(1, OpEqual, 5).ExternalCondition(board), ShouldBeTrue)
+		So(CompareInt(1, OpNotEqual, 5).ExternalCondition(board), ShouldBeFalse)
+		So(CompareInt(1, OpGreaterEqual, 6).ExternalCondition(board), ShouldBeFalse)
+		So(CompareInt(1, OpGreater, 4).ExternalCondition(board), ShouldBeTrue)
+		So(CompareFloat(3, OpLess, 1).ExternalCondition(board), ShouldBeTrue)
+		So(CompareFloat(4, OpGreater, 2).ExternalCondition(board), ShouldBeTrue)
+		So(CompareString(5, OpEqual, "idle").ExternalCondition(board), ShouldBeTrue)
+		So(CompareString(5, OpLess, "attack").ExternalCondition(board), ShouldBeFalse)
+		So(CompareBool(6, OpEqual, true).ExternalCondition(board), ShouldBeTrue)
+		So(CompareBool(6, OpGreater, false).ExternalCondition(board), ShouldBeTrue)
+	})
+
+	Convey("Values are compared with other keys", t, func() {
+		So(CompareIntKeys(1, OpLess, 2).ExternalCondition(board), ShouldBeTrue)
+		So(CompareIntKeys(2, OpLess, 1).ExternalCondition(board), ShouldBeFalse)
+		So(CompareFloatKeys(3, OpLess, 4).ExternalCondition(board), ShouldBeTrue)
+		So(CompareStringKeys(5, OpEqual, 5).ExternalCondition(board), ShouldBeTrue)
+		So(CompareBoolKeys(6, OpNotEqual, 6).ExternalCondition(board), ShouldBeFalse)
+	})
+
+	Convey("Ranges, existence and nil", t, func() {
+		So(InRangeInt(1, 5, 10).ExternalCondition(board), ShouldBeTrue)
+		So(InRangeInt(1, 6, 10).ExternalCondition(board), ShouldBeFalse)
+		So(InRangeFloat(3, 0, 1).ExternalCondition(board), ShouldBeTrue)
+		So(KeyExists(7).ExternalCondition(board), ShouldBeTrue)
+		So(KeyExists(8).ExternalCondition(board), ShouldBeFalse)
+		So(KeyIsNil(7).ExternalCondition(board), ShouldBeTrue)
+		So(KeyIsNil(1).ExternalCondition(board), ShouldBeFalse)
+	})
+
+	Convey("NaN is unordered", t, func() {
+		board := bb.NewBlackboard()
+		board.SetValueAsFloat64(1, math.NaN())
+		board.SetValueAsFloat64(2, 5)
+		So(CompareFloat(1, OpEqual, 5).ExternalCondition(board), ShouldBeFalse)
+		So(CompareFloat(1, OpLessEqual, 5).ExternalCondition(board), ShouldBeFalse)
+		So(CompareFloat(1, OpGreaterEqual, 5).ExternalCondition(board), ShouldBeFalse)
+		So(CompareFloat(1, OpNotEqual, 5).ExternalCondition(board), ShouldBeTrue)
+		So(CompareFloat(2, OpEqual, math.NaN()).ExternalCondition(board), ShouldBeFalse)
+		So(CompareFloatKeys(1, OpNotEqual, 1).ExternalCondition(board), ShouldBeTrue)
+		So(InRangeFloat(1, 0, 1).ExternalCondition(board), ShouldBeFalse)
+		So(InRangeFloat(2, math.NaN(), 10).ExternalCondition(board), ShouldBeFalse)
+	})
+
+	Convey("Operators apply to values of the same type", t, func() {
+		result, err := OpLess.Apply(1, 2)
+		So(result, ShouldBeTrue)
+		So(err, ShouldBeNil)
+		result, err = OpEqual.Apply(math.NaN(), math.NaN())
+		So(result, ShouldBeFalse)
+		So(err, ShouldBeNil)
+		_, err = OpLess.Apply(1, 1.5)
+		So(err.Error(), ShouldEqual, "can not compare int with float64")
+		_, err = OpEqual.Apply([]int{}, []int{})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Missing keys and wrong types follow the policies", t, func() {
+		So(CompareInt(8, OpLess, 10).ExternalCondition(board), ShouldBeFalse)
+		So(CompareInt(8, OpLess, 10).OnMissing(PolicyTrue).ExternalCondition(board), ShouldBeTrue)
+		So(CompareIntKeys(1, OpLess, 8).OnMissing(PolicyTrue).ExternalCondition(board), ShouldBeTrue)
+		So(CompareInt(5, OpLess, 10).OnMissing(PolicyTrue).ExternalCondition(board), ShouldBeFalse)
+		So(CompareInt(5, OpLess, 10).OnWrongType(PolicyTrue).ExternalCondition(board), ShouldBeTrue)
+		So(CompareFloat(1, OpLess, 10).ExternalCondition(board), ShouldBeFalse)
+		So(CompareInt(1, OpLess, 10).OnWrongType(PolicyTrue).ExternalCondition(nil), ShouldBeTrue)
+		var recovered interface{}
+		func() {
+			defer func() { recovered = recover() }()
+			CompareInt(8, OpLess, 10).OnMissing(PolicyPanic).ExternalCondition(board)
+		}()
+		So(recovered, ShouldImplement, (*error)(nil))
+		So(recovered.(error).Error(), ShouldEqual, "precondition $8 < 10: key 8: Invalid Key")
+	})
+
+	Convey("PolicyError makes Check report why the precondition is false", t, func() {
+		result, err := CompareInt(1, OpLess, 10).Check(board)
+		So(result, ShouldBeTrue)
+		So(err, ShouldBeNil)
+
+		cond := CompareInt(8, OpLess, 10).OnMissing(PolicyError)
+		So(cond.ExternalCondition(board), ShouldBeFalse)
+		result, err = cond.Check(board)
+		So(result, ShouldBeFalse)
+		So(err.Error(), ShouldEqual, "precondition $8 < 10: key 8: Invalid Key")
+		So(errors.Is(err, bb.ErrInvalidKey), ShouldBeTrue)
+		var condErr *PreconditionError
+		So(errors.As(err, &condErr), ShouldBeTrue)
+		So(condErr.Cond, ShouldEqual, cond)
+
+		_, err = CompareFloat(1, OpLess, 10).OnWrongType(PolicyError).Check(board)
+		So(errors.Is(err, bb.ErrInvalidType), ShouldBeTrue)
+		_, err = CompareInt(1, OpLess, 10).OnWrongType(PolicyError).Check("board")
+		So(errors.Is(err, ErrNotBlackboard), ShouldBeTrue)
+		So(err.Error(), ShouldEqual, "precondition $1 < 10: input is not a blackboard, got string")
+		_, err = CompareInt(8, OpLess, 10).OnWrongType(PolicyError).Check(board)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Board preconditions have a readable String", t, func() {
+		So(Describe(CompareIntKeys(1, OpLess, 2)), ShouldEqual, "$1 < $2")
+		So(Describe(CompareString(5, OpNotEqual, "idle")), ShouldEqual, `$5 != "idle"`)
+		So(Describe(All(InRangeFloat(3, 0, 1.5), KeyExists(7), KeyIsNil(7))), ShouldEqual, "all($3 in [0, 1.5], exists($7), $7 == nil)")
+	})
+
+	Convey("Operators are parsed", t, func() {
+		op, err := ParseOperator(">=")
+		So(err, ShouldBeNil)
+		So(op, ShouldEqual, OpGreaterEqual)
+		_, err = ParseOperator("=")
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,142 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package precondition
+
+import (
+	"reflect"
+)
+
+/*
+ * Cache remembers the results of preconditions for one frame, so that
+ * expensive ones run once per tick even when several nodes share them or a
+ * selector evaluates a child twice. Results are keyed by the precondition
+ * itself, so a cache must only be used with one input, eg. the blackboard of
+ * one agent. BevTree.SetPreconditionCache evaluates the node preconditions of
+ * a tree through a cache, Wrap caches a precondition nested in a combinator.
+ */
+type Cache struct {
+	frame   int
+	results map[IPrecondition]cachedResult
+	hits    int
+	misses  int
+}
+
+type cachedResult struct {
+	result bool
+	err    error
+}
+
+func NewCache() *Cache {
+	return &Cache{frame: -1, results: map[IPrecondition]cachedResult{}}
+}
+
+// SetFrame forgets the results when frame is not the current frame
+func (c *Cache) SetFrame(frame int) {
+	if frame != c.frame {
+		c.frame = frame
+		c.InvalidateAll()
+	}
+}
+
+func (c *Cache) Frame() int {
+	return c.frame
+}
+
+// Evaluate returns the result of cond for this frame, it is evaluated on the
+// first call only. Preconditions which can not be map keys are always evaluated.
+func (c *Cache) Evaluate(cond IPrecondition, input interface{}) bool {
+	result, _ := c.Check(cond, input)
+	return result
+}
+
+// Check is Evaluate for IErrorPrecondition, errors are cached with the results
+func (c *Cache) Check(cond IPrecondition, input interface{}) (bool, error) {
+	if !reflect.TypeOf(cond).Comparable() {
+		return Check(cond, input)
+	}
+	if cached, ok := c.results[cond]; ok {
+		c.hits++
+		return cached.result, cached.err
+	}
+	c.misses++
+	result, err := Check(cond, input)
+	c.results[cond] = cachedResult{result, err}
+	return result, err
+}
+
+// Invalidate forgets the result of cond, eg. after writing a key it reads
+func (c *Cache) Invalidate(cond IPrecondition) {
+	if reflect.TypeOf(cond).Comparable() {
+		delete(c.results, cond)
+	}
+}
+
+// InvalidateAll forgets every result of the frame
+func (c *Cache) InvalidateAll() {
+	c.results = map[IPrecondition]cachedResult{}
+}
+
+// number of evaluations answered by the cache and of evaluations run, since the cache was created
+func (c *Cache) Stats() (hits int, misses int) {
+	return c.hits, c.misses
+}
+
+// Wrap returns a precondition evaluating cond through the cache. Its clones,
+// see ClonePrecondition, evaluate a clone of cond through a cache of their own.
+func (c *Cache) Wrap(cond IPrecondition) *PreconditionCached {
+	return &PreconditionCached{c, cond}
+}
+
+// evaluates its precondition through a Cache, see Cache.Wrap
+type PreconditionCached struct {
+	cache *Cache
+	cond  IPrecondition
+}
+
+func (Cond *PreconditionCached) ExternalCondition(input interface{}) bool {
+	return Cond.cache.Evaluate(Cond.cond, input)
+}
+
+func (Cond *PreconditionCached) Check(input interface{}) (bool, error) {
+	return Cond.cache.Check(Cond.cond, input)
+}
+
+// Cache returns the cache the precondition is evaluated through, eg. to call
+// SetFrame on the cache of a clone
+func (Cond *PreconditionCached) Cache() *Cache {
+	return Cond.cache
+}
+
+// ClonePrecondition gives the clone an empty cache at the frame of the cache of
+// Cond, so that trees cloned for several agents do not share results
+func (Cond *PreconditionCached) ClonePrecondition() IPrecondition {
+	cache := NewCache()
+	cache.frame = Cond.cache.frame
+	return cache.Wrap(ClonePrecondition(Cond.cond))
+}
+
+// the cached precondition is described, caching does not change its meaning
+func (Cond *PreconditionCached) String() string {
+	return Describe(Cond.cond)
+}
@@ -0,0 +1,159 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package precondition
+
+import (
+	"strconv"
+	"strings"
+)
+
+// return true if the precondition returns false
+type PreconditionNOT struct {
+	operand IPrecondition
+}
+
+func Not(operand IPrecondition) *PreconditionNOT {
+	return &PreconditionNOT{operand}
+}
+
+func (Cond *PreconditionNOT) ExternalCondition(input interface{}) bool {
+	result, _ := Cond.Check(input)
+	return result
+}
+
+// an error of the operand makes NOT false too
+func (Cond *PreconditionNOT) Check(input interface{}) (bool, error) {
+	result, err := Check(Cond.operand, input)
+	if err != nil {
+		return false, err
+	}
+	return !result, nil
+}
+
+func (Cond *PreconditionNOT) String() string {
+	return describeCall("not", Cond.operand)
+}
+
+// return true if exactly one of the preconditions returns true, both are always evaluated
+type PreconditionXOR struct {
+	first  IPrecondition
+	second IPrecondition
+}
+
+func Xor(first IPrecondition, second IPrecondition) *PreconditionXOR {
+	return &PreconditionXOR{first, second}
+}
+
+func (Cond *PreconditionXOR) ExternalCondition(input interface{}) bool {
+	result, _ := Cond.Check(input)
+	return result
+}
+
+func (Cond *PreconditionXOR) Check(input interface{}) (bool, error) {
+	first, err := Check(Cond.first, input)
+	if err != nil {
+		return false, err
+	}
+	second, err := Check(Cond.second, input)
+	if err != nil {
+		return false, err
+	}
+	return first != second, nil
+}
+
+func (Cond *PreconditionXOR) String() string {
+	return describeCall("xor", Cond.first, Cond.second)
+}
+
+/*
+ * PreconditionCount returns true if the number of operands returning true is
+ * between min and max, max < 0 meaning no upper bound. Operands are evaluated
+ * in order until the result is known. It is created by All, Any, None and AtLeast.
+ */
+type PreconditionCount struct {
+	name     string
+	min      int
+	max      int
+	operands []IPrecondition
+}
+
+// return true if all the preconditions return true, or if there is none
+func All(operands ...IPrecondition) *PreconditionCount {
+	return &PreconditionCount{"all", len(operands), -1, operands}
+}
+
+// return true if one of the preconditions returns true
+func Any(operands ...IPrecondition) *PreconditionCount {
+	return &PreconditionCount{"any", 1, -1, operands}
+}
+
+// return true if none of the preconditions returns true
+func None(operands ...IPrecondition) *PreconditionCount {
+	return &PreconditionCount{"none", 0, 0, operands}
+}
+
+// return true if k of the preconditions or more return true
+func AtLeast(k int, operands ...IPrecondition) *PreconditionCount {
+	return &PreconditionCount{"atLeast", k, -1, operands}
+}
+
+func (Cond *PreconditionCount) ExternalCondition(input interface{}) bool {
+	result, _ := Cond.Check(input)
+	return result
+}
+
+func (Cond *PreconditionCount) Check(input interface{}) (bool, error) {
+	count := 0
+	for i, operand := range Cond.operands {
+		if count >= Cond.min && Cond.max < 0 {
+			return true, nil
+		}
+		if count+len(Cond.operands)-i < Cond.min {
+			return false, nil
+		}
+		result, err := Check(operand, input)
+		if err != nil {
+			return false, err
+		}
+		if result {
+			count++
+			if Cond.max >= 0 && count > Cond.max {
+				return false, nil
+			}
+		}
+	}
+	return count >= Cond.min, nil
+}
+
+func (Cond *PreconditionCount) String() string {
+	if Cond.name != "atLeast" {
+		return describeCall(Cond.name, Cond.operands...)
+	}
+	// the number of operands to satisfy comes first, eg. "atLeast(2, a, b, c)"
+	args := []string{strconv.Itoa(Cond.min)}
+	for _, operand := range Cond.operands {
+		args = append(args, Describe(operand))
+	}
+	return "atLeast(" + strings.Join(args, ", ") + ")"
+}
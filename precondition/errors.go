@@ -0,0 +1,100 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package precondition
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotBlackboard = errors.New("input is not a blackboard")
+)
+
+/*
+ * IErrorPrecondition is a precondition which can tell why it could not be
+ * evaluated, eg. a missing key. Check returns false with the error, and
+ * ExternalCondition returns false where Check fails, so an IErrorPrecondition
+ * can be used wherever an IPrecondition is. The combinators implement it and
+ * return the first error of their operands.
+ */
+type IErrorPrecondition interface {
+	IPrecondition
+	Check(input interface{}) (bool, error)
+}
+
+// Check evaluates cond with its Check if it is an IErrorPrecondition
+func Check(cond IPrecondition, input interface{}) (bool, error) {
+	if Cond, ok := cond.(IErrorPrecondition); ok {
+		return Cond.Check(input)
+	}
+	return cond.ExternalCondition(input), nil
+}
+
+// PreconditionError is an error of the precondition Cond
+type PreconditionError struct {
+	Cond IPrecondition
+	Err  error
+}
+
+func (e *PreconditionError) Error() string {
+	return fmt.Sprintf("precondition %s: %v", Describe(e.Cond), e.Err)
+}
+
+func (e *PreconditionError) Unwrap() error {
+	return e.Err
+}
+
+type CheckFunc func(input interface{}) (bool, error)
+
+// Checked adapts f to IErrorPrecondition, name is its String
+func Checked(name string, f CheckFunc) *PreconditionChecked {
+	return &PreconditionChecked{name, f}
+}
+
+type PreconditionChecked struct {
+	name string
+	f    CheckFunc
+}
+
+func (Cond *PreconditionChecked) ExternalCondition(input interface{}) bool {
+	result, _ := Cond.Check(input)
+	return result
+}
+
+func (Cond *PreconditionChecked) Check(input interface{}) (bool, error) {
+	result, err := Cond.f(input)
+	if err != nil {
+		var condErr *PreconditionError
+		if !errors.As(err, &condErr) {
+			err = &PreconditionError{Cond, err}
+		}
+		return false, err
+	}
+	return result, nil
+}
+
+func (Cond *PreconditionChecked) String() string {
+	return Cond.name
+}
@@ -0,0 +1,147 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package precondition
+
+/*
+ * Evaluation is the value of a precondition and of its operands, see Evaluate.
+ * Operands which the evaluation short-circuits are Skipped and have no value.
+ */
+type Evaluation struct {
+	Expr     string        `json:"expr"`
+	Value    bool          `json:"value"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Operands []*Evaluation `json:"operands,omitempty"`
+}
+
+// IPreconditionWrapper is a precondition evaluating another one, eg. a compiled
+// expression, which Evaluate explains through
+type IPreconditionWrapper interface {
+	IPrecondition
+	Precondition() IPrecondition
+}
+
+/*
+ * Evaluate evaluates cond like Check and records the value of every operand of
+ * the AND, OR, NOT, XOR and counting combinators, in the order they are
+ * evaluated. Cached ones are evaluated without reading or filling the cache,
+ * wrappers by their inner precondition under their own description. Other
+ * preconditions are evaluated as a whole.
+ */
+func Evaluate(cond IPrecondition, input interface{}) *Evaluation {
+	e := &Evaluation{Expr: Describe(cond)}
+	switch Cond := cond.(type) {
+	case *PreconditionAND:
+		first := Evaluate(Cond.first, input)
+		e.Operands = []*Evaluation{first, skipped(Cond.second)}
+		if first.Value && first.Error == "" {
+			e.Operands[1] = Evaluate(Cond.second, input)
+		}
+		e.conclude(e.Operands[len(e.Operands)-1].Value && first.Value)
+	case *PreconditionOR:
+		first := Evaluate(Cond.first, input)
+		e.Operands = []*Evaluation{first, skipped(Cond.second)}
+		if !first.Value && first.Error == "" {
+			e.Operands[1] = Evaluate(Cond.second, input)
+		}
+		e.conclude(first.Value || e.Operands[1].Value)
+	case *PreconditionNOT:
+		operand := Evaluate(Cond.operand, input)
+		e.Operands = []*Evaluation{operand}
+		e.conclude(!operand.Value)
+	case *PreconditionXOR:
+		first := Evaluate(Cond.first, input)
+		e.Operands = []*Evaluation{first, skipped(Cond.second)}
+		if first.Error == "" {
+			e.Operands[1] = Evaluate(Cond.second, input)
+		}
+		e.conclude(first.Value != e.Operands[1].Value)
+	case *PreconditionCount:
+		e.explainCount(Cond, input)
+	case *PreconditionCached:
+		inner := Evaluate(Cond.cond, input)
+		inner.Expr = e.Expr
+		return inner
+	case IPreconditionWrapper:
+		inner := Evaluate(Cond.Precondition(), input)
+		inner.Expr = e.Expr
+		return inner
+	default:
+		value, err := Check(cond, input)
+		e.Value = value
+		if err != nil {
+			e.Error = err.Error()
+		}
+	}
+	return e
+}
+
+func skipped(cond IPrecondition) *Evaluation {
+	return &Evaluation{Expr: Describe(cond), Skipped: true}
+}
+
+// set the value of a combinator, false with the error of the first failing operand
+func (e *Evaluation) conclude(value bool) {
+	for _, operand := range e.Operands {
+		if operand.Error != "" {
+			e.Error = operand.Error
+			return
+		}
+	}
+	e.Value = value
+}
+
+func (e *Evaluation) explainCount(Cond *PreconditionCount, input interface{}) {
+	count := 0
+	decided, value := false, false
+	for i, operand := range Cond.operands {
+		if !decided {
+			switch {
+			case count >= Cond.min && Cond.max < 0:
+				decided, value = true, true
+			case count+len(Cond.operands)-i < Cond.min:
+				decided = true
+			}
+		}
+		if decided {
+			e.Operands = append(e.Operands, skipped(operand))
+			continue
+		}
+
+		o := Evaluate(operand, input)
+		e.Operands = append(e.Operands, o)
+		if o.Error != "" {
+			decided = true
+		} else if o.Value {
+			count++
+			if Cond.max >= 0 && count > Cond.max {
+				decided = true
+			}
+		}
+	}
+	if !decided {
+		value = count >= Cond.min
+	}
+	e.conclude(value)
+}
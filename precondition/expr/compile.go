@@ -0,0 +1,212 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package expr
+
+import (
+	"fmt"
+
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+// type checks the AST and turns it into preconditions
+type compiler struct {
+	src string
+	env *Env
+}
+
+func (c *compiler) errorf(n exprNode, format string, a ...interface{}) error {
+	return errorf(c.src, n.pos(), format, a...)
+}
+
+// condition compiles a node used as a bool
+func (c *compiler) condition(n exprNode) (p.IPrecondition, error) {
+	switch n := n.(type) {
+	case *logicalNode:
+		operands := make([]p.IPrecondition, len(n.operands))
+		for i, operand := range n.operands {
+			cond, err := c.condition(operand)
+			if err != nil {
+				return nil, err
+			}
+			operands[i] = cond
+		}
+		if n.op == tokAnd {
+			return p.All(operands...), nil
+		}
+		return p.Any(operands...), nil
+
+	case *notNode:
+		operand, err := c.condition(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		return p.Not(operand), nil
+
+	case *literalNode:
+		if n.typ != Bool {
+			return nil, c.errorf(n, "%s %v is not a condition", n.typ, n.value)
+		}
+		if n.value.(bool) {
+			return p.NewPreconditionTRUE(), nil
+		}
+		return p.NewPreconditionFALSE(), nil
+
+	case *identNode:
+		v, err := c.lookup(n)
+		if err != nil {
+			return nil, err
+		}
+		if v.Type != Bool {
+			return nil, c.errorf(n, "%s is %s, not a condition, compare it with a value", v.Name, v.Type)
+		}
+		return c.policies(p.CompareBool(v.Key, p.OpEqual, true)), nil
+
+	case *compareNode:
+		return c.compare(n)
+	}
+	return nil, c.errorf(n, "unexpected expression")
+}
+
+func (c *compiler) lookup(n *identNode) (Var, error) {
+	v, ok := c.env.Lookup(n.name)
+	if !ok {
+		return v, c.errorf(n, "unknown name %q", n.name)
+	}
+	return v, nil
+}
+
+func (c *compiler) policies(cond *p.PreconditionBoard) p.IPrecondition {
+	return cond.OnMissing(c.env.onMissing).OnWrongType(c.env.onWrongType)
+}
+
+// operand of a comparison, either a declared key or a literal
+type value struct {
+	node    exprNode
+	typ     Type
+	v       *Var
+	literal interface{}
+}
+
+func (c *compiler) value(n exprNode) (value, error) {
+	switch n := n.(type) {
+	case *identNode:
+		v, err := c.lookup(n)
+		if err != nil {
+			return value{}, err
+		}
+		return value{node: n, typ: v.Type, v: &v}, nil
+	case *literalNode:
+		return value{node: n, typ: n.typ, literal: n.value}, nil
+	}
+	return value{}, c.errorf(n, "expected a value, got a condition")
+}
+
+func (val value) String() string {
+	if val.v != nil {
+		return val.typ.String() + " " + val.v.Name
+	}
+	if s, ok := val.literal.(string); ok {
+		return fmt.Sprintf("string %q", s)
+	}
+	return fmt.Sprintf("%s %v", val.typ, val.literal)
+}
+
+// the operator seen from the other operand, a < b is b > a
+var flipped = map[p.Operator]p.Operator{
+	p.OpLess:         p.OpGreater,
+	p.OpLessEqual:    p.OpGreaterEqual,
+	p.OpEqual:        p.OpEqual,
+	p.OpNotEqual:     p.OpNotEqual,
+	p.OpGreaterEqual: p.OpLessEqual,
+	p.OpGreater:      p.OpLess,
+}
+
+func (c *compiler) compare(n *compareNode) (p.IPrecondition, error) {
+	op, err := p.ParseOperator(n.op)
+	if err != nil {
+		return nil, c.errorf(n, "%v", err)
+	}
+	left, err := c.value(n.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.value(n.right)
+	if err != nil {
+		return nil, err
+	}
+	if left.v == nil && right.v != nil {
+		left, right, op = right, left, flipped[op]
+	}
+
+	// an int literal is compared as a float with a float
+	if right.v == nil && left.typ == Float && right.typ == Int {
+		right.typ, right.literal = Float, float64(right.literal.(int))
+	}
+	if left.v == nil && left.typ == Int && right.typ == Float {
+		left.typ, left.literal = Float, float64(left.literal.(int))
+	}
+	if left.typ != right.typ {
+		return nil, c.errorf(n, "can not compare %s with %s", left, right)
+	}
+	if left.typ == Bool && op != p.OpEqual && op != p.OpNotEqual {
+		return nil, c.errorf(n, "bools are only compared with == and !=")
+	}
+
+	if left.v == nil {
+		// two literals
+		result, err := op.Apply(left.literal, right.literal)
+		if err != nil {
+			return nil, c.errorf(n, "%v", err)
+		}
+		if result {
+			return p.NewPreconditionTRUE(), nil
+		}
+		return p.NewPreconditionFALSE(), nil
+	}
+
+	key := left.v.Key
+	if right.v != nil {
+		other := right.v.Key
+		switch left.typ {
+		case Int:
+			return c.policies(p.CompareIntKeys(key, op, other)), nil
+		case Float:
+			return c.policies(p.CompareFloatKeys(key, op, other)), nil
+		case String:
+			return c.policies(p.CompareStringKeys(key, op, other)), nil
+		default:
+			return c.policies(p.CompareBoolKeys(key, op, other)), nil
+		}
+	}
+	switch left.typ {
+	case Int:
+		return c.policies(p.CompareInt(key, op, right.literal.(int))), nil
+	case Float:
+		return c.policies(p.CompareFloat(key, op, right.literal.(float64))), nil
+	case String:
+		return c.policies(p.CompareString(key, op, right.literal.(string))), nil
+	default:
+		return c.policies(p.CompareBool(key, op, right.literal.(bool))), nil
+	}
+}
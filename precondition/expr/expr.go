@@ -0,0 +1,174 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+/*
+ * Package expr compiles guards written by designers into preconditions
+ * evaluated against a blackboard:
+ *
+ *	env := expr.NewEnv().
+ *		Declare("hp", 1, expr.Int).
+ *		Declare("ammo", 2, expr.Int).
+ *		Declare("hasMelee", 3, expr.Bool).
+ *		Declare("fleeing", 4, expr.Bool)
+ *	cond, err := expr.Compile("hp < 30 && (ammo > 0 || hasMelee) && !fleeing", env)
+ *
+ * Operands are declared names, numbers, "strings", true and false. Comparisons
+ * are ==, !=, <, <=, > and >=, and a bool name alone is a condition. && binds
+ * tighter than ||, ! tighter than both. Operands of a comparison must have the
+ * same type, except int literals which may be compared with floats. The result
+ * is built from the preconditions of package precondition.
+ */
+package expr
+
+import (
+	"fmt"
+	"sort"
+
+	p "github.com/ShionRyuu/gobevtree/precondition"
+)
+
+/*
+ * Type of a declared key
+ */
+const (
+	Int Type = iota
+	Float
+	String
+	Bool
+)
+
+type Type int
+
+func (t Type) String() string {
+	switch t {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case String:
+		return "string"
+	case Bool:
+		return "bool"
+	}
+	return fmt.Sprintf("type(%d)", int(t))
+}
+
+// Var is a blackboard key declared in an Env
+type Var struct {
+	Name string
+	Key  int
+	Type Type
+}
+
+/*
+ * Env holds the names an expression may use
+ */
+type Env struct {
+	vars        map[string]Var
+	onMissing   p.KeyPolicy
+	onWrongType p.KeyPolicy
+}
+
+func NewEnv() *Env {
+	return &Env{vars: map[string]Var{}}
+}
+
+// Declare names key of the blackboard, the value stored there must be of type typ
+func (env *Env) Declare(name string, key int, typ Type) *Env {
+	env.vars[name] = Var{name, key, typ}
+	return env
+}
+
+// Policies sets what comparisons of compiled expressions return when a key is
+// missing or holds another type, see precondition.KeyPolicy, both are PolicyFalse by default
+func (env *Env) Policies(onMissing, onWrongType p.KeyPolicy) *Env {
+	env.onMissing, env.onWrongType = onMissing, onWrongType
+	return env
+}
+
+func (env *Env) Lookup(name string) (Var, bool) {
+	v, ok := env.vars[name]
+	return v, ok
+}
+
+// sorted declared names
+func (env *Env) Names() []string {
+	names := make([]string, 0, len(env.vars))
+	for name := range env.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+ * Expression is a compiled expression, its String is the source
+ */
+type Expression struct {
+	source string
+	cond   p.IPrecondition
+}
+
+func (e *Expression) ExternalCondition(input interface{}) bool {
+	return e.cond.ExternalCondition(input)
+}
+
+// Check returns the error of a comparison whose env policy is PolicyError
+func (e *Expression) Check(input interface{}) (bool, error) {
+	return p.Check(e.cond, input)
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Precondition returns what the expression compiled to
+func (e *Expression) Precondition() p.IPrecondition {
+	return e.cond
+}
+
+// Compile parses and type checks src, errors are *Error, nil env declares no name
+func Compile(src string, env *Env) (*Expression, error) {
+	tree, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		env = NewEnv()
+	}
+	c := &compiler{src: src, env: env}
+	cond, err := c.condition(tree)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{src, cond}, nil
+}
+
+// MustCompile is like Compile but panics on error, for expressions known to be valid
+func MustCompile(src string, env *Env) *Expression {
+	e, err := Compile(src, env)
+	if err != nil {
+		panic(fmt.Sprintf("expr: %q: %v", src, err))
+	}
+	return e
+}
@@ -0,0 +1,131 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package expr
+
+import (
+	"testing"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	p "github.com/ShionRyuu/gobevtree/precondition"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newEnv() *Env {
+	return NewEnv().
+		Declare("hp", 1, Int).
+		Declare("ammo", 2, Int).
+		Declare("hasMelee", 3, Bool).
+		Declare("fleeing", 4, Bool).
+		Declare("speed", 5, Float).
+		Declare("state", 6, String).
+		Declare("maxHp", 7, Int)
+}
+
+func TestCompile(t *testing.T) {
+	board := bb.NewBlackboard()
+	board.SetValueAsInt(1, 20)
+	board.SetValueAsInt(2, 0)
+	board.SetValueAsBool(3, true)
+	board.SetValueAsBool(4, false)
+	board.SetValueAsFloat64(5, 1.5)
+	board.SetValueAsString(6, "patrol")
+	board.SetValueAsInt(7, 100)
+	env := newEnv()
+
+	Convey("Expressions are evaluated against the blackboard", t, func() {
+		for src, want := range map[string]bool{
+			"hp < 30 && (ammo > 0 || hasMelee) && !fleeing": true,
+			"hp < 30 && ammo > 0 || fleeing":                false,
+			"!(hp >= 30) && !!hasMelee":                     true,
+			"30 > hp":                                       true,
+			"hp <= maxHp && maxHp != hp":                    true,
+			"speed > 1 && speed < 1.75 && speed >= -2.5":    true,
+			`state == "patrol" && state != "flee"`:          true,
+			"hasMelee == true && fleeing != hasMelee":       true,
+			"1 < 2 && !false":                               true,
+			`"a" > "b" || true == false`:                    false,
+		} {
+			cond, err := Compile(src, env)
+			So(err, ShouldBeNil)
+			So(cond.ExternalCondition(board), ShouldEqual, want)
+			So(cond.String(), ShouldEqual, src)
+		}
+	})
+
+	Convey("Expressions compile to the combinators and board preconditions", t, func() {
+		cond := MustCompile("hp < 30 && (ammo > 0 || hasMelee) && !fleeing", env)
+		So(p.Describe(cond.Precondition()), ShouldEqual, "all($1 < 30, any($2 > 0, $3 == true), not($4 == true))")
+		So(p.Describe(MustCompile("10 <= hp", env).Precondition()), ShouldEqual, "$1 >= 10")
+	})
+
+	Convey("Missing keys follow the policies of the env", t, func() {
+		env := NewEnv().Declare("shield", 9, Int)
+		So(MustCompile("shield < 1", env).ExternalCondition(board), ShouldBeFalse)
+		env.Policies(p.PolicyTrue, p.PolicyFalse)
+		So(MustCompile("shield < 1", env).ExternalCondition(board), ShouldBeTrue)
+	})
+
+	Convey("Errors tell what is wrong and where", t, func() {
+		for src, want := range map[string]string{
+			"hp < 30 && ammo >":         "column 18: expected operand, got end of expression",
+			"hp < 30 & ammo":            `column 9: unexpected "&", did you mean "&&"`,
+			"hp = 30":                   `column 4: unexpected "=", did you mean "=="`,
+			"(hp < 30":                  "column 9: expected ')' to close '(' at column 1, got end of expression",
+			"hp < 30)":                  "column 8: unexpected ')'",
+			"hpp < 30":                  `column 1: unknown name "hpp"`,
+			"hp":                        "column 1: hp is int, not a condition, compare it with a value",
+			"hp < 30 && 42":             "column 12: int 42 is not a condition",
+			`hp == "full"`:              `column 4: can not compare int hp with string "full"`,
+			"hp < 2.5":                  "column 4: can not compare int hp with float 2.5",
+			"hasMelee < fleeing":        "column 10: bools are only compared with == and !=",
+			"0 < hp < 30":               "column 8: comparisons can not be chained, use && or parentheses",
+			"(hp < 30) == true":         "column 5: expected a value, got a condition",
+			`state == "idle`:            "column 10: unterminated string",
+			"hp < 3.0.1":                "column 9: unexpected '.' in number",
+			"hp < 3.":                   "column 7: number can not end with '.'",
+			"hp < - ammo":               "column 8: expected number after '-', got 'ammo'",
+			"état < 1":                  `column 1: unknown name "état"`,
+			"hp < 1 && état # 1":        `column 16: unexpected character "#"`,
+			"hp < 99999999999999999999": "column 6: integer 99999999999999999999 out of range",
+		} {
+			_, err := Compile(src, env)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, want)
+		}
+	})
+
+	Convey("A nil env declares no name", t, func() {
+		_, err := Compile("hp < 30", nil)
+		So(err.Error(), ShouldEqual, `column 1: unknown name "hp"`)
+		e, err := Compile("1 < 2 && true", nil)
+		So(err, ShouldBeNil)
+		So(e.Precondition().ExternalCondition(nil), ShouldBeTrue)
+	})
+
+	Convey("Caret points at the problem", t, func() {
+		_, err := Compile("hp < 30 && ammo >", env)
+		So(err.(*Error).Caret(), ShouldEqual, "hp < 30 && ammo >\n                 ^ expected operand, got end of expression")
+		So(func() { MustCompile("hp", env) }, ShouldPanic)
+	})
+}
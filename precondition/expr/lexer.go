@@ -0,0 +1,176 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokInt
+	tokFloat
+	tokString
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokMinus
+	tokCompare // ==, !=, <, <=, >, >=
+)
+
+var tokenNames = map[tokenKind]string{
+	tokEOF:     "end of expression",
+	tokIdent:   "identifier",
+	tokInt:     "integer",
+	tokFloat:   "float",
+	tokString:  "string",
+	tokLParen:  "'('",
+	tokRParen:  "')'",
+	tokAnd:     "'&&'",
+	tokOr:      "'||'",
+	tokNot:     "'!'",
+	tokMinus:   "'-'",
+	tokCompare: "comparison",
+}
+
+func (kind tokenKind) String() string {
+	return tokenNames[kind]
+}
+
+type token struct {
+	kind tokenKind
+	text string // source of the token, unquoted for strings
+	pos  int    // byte offset in the source
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return t.kind.String()
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// operators of two characters first
+var operators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"&&", tokAnd}, {"||", tokOr},
+	{"==", tokCompare}, {"!=", tokCompare}, {"<=", tokCompare}, {">=", tokCompare},
+	{"<", tokCompare}, {">", tokCompare},
+	{"!", tokNot}, {"(", tokLParen}, {")", tokRParen}, {"-", tokMinus},
+}
+
+// lex splits src into tokens, the last one is tokEOF
+func lex(src string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for {
+		for pos < len(src) && (src[pos] == ' ' || src[pos] == '\t' || src[pos] == '\n' || src[pos] == '\r') {
+			pos++
+		}
+		if pos == len(src) {
+			return append(tokens, token{tokEOF, "", pos}), nil
+		}
+
+		start := pos
+		r, size := utf8.DecodeRuneInString(src[pos:])
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+			for pos < len(src) {
+				r, size = utf8.DecodeRuneInString(src[pos:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				pos += size
+			}
+			tokens = append(tokens, token{tokIdent, src[start:pos], start})
+
+		case r >= '0' && r <= '9':
+			kind := tokInt
+			for pos < len(src) && (src[pos] >= '0' && src[pos] <= '9' || src[pos] == '.') {
+				if src[pos] == '.' {
+					if kind == tokFloat {
+						return nil, errorf(src, pos, "unexpected '.' in number")
+					}
+					kind = tokFloat
+				}
+				pos++
+			}
+			if src[pos-1] == '.' {
+				return nil, errorf(src, pos-1, "number can not end with '.'")
+			}
+			tokens = append(tokens, token{kind, src[start:pos], start})
+
+		case r == '"':
+			var b strings.Builder
+			pos++
+			for {
+				if pos >= len(src) {
+					return nil, errorf(src, start, "unterminated string")
+				}
+				c := src[pos]
+				if c == '"' {
+					pos++
+					break
+				}
+				if c == '\\' && pos+1 < len(src) && (src[pos+1] == '"' || src[pos+1] == '\\') {
+					pos++
+					c = src[pos]
+				}
+				b.WriteByte(c)
+				pos++
+			}
+			tokens = append(tokens, token{tokString, b.String(), start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[pos:], op.text) {
+					tokens = append(tokens, token{op.kind, op.text, start})
+					pos += len(op.text)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				if r == '&' || r == '|' || r == '=' {
+					return nil, errorf(src, pos, "unexpected %q, did you mean %q", string(r), strings.Repeat(string(r), 2))
+				}
+				return nil, errorf(src, pos, "unexpected character %q", string(r))
+			}
+		}
+	}
+}
@@ -0,0 +1,251 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error of Compile, Pos is the byte offset of the problem in Source
+type Error struct {
+	Source string
+	Pos    int
+	Msg    string
+}
+
+func errorf(src string, pos int, format string, a ...interface{}) *Error {
+	return &Error{src, pos, fmt.Sprintf(format, a...)}
+}
+
+// Column of the problem, counted in characters from 1
+func (e *Error) Column() int {
+	return utf8.RuneCountInString(e.Source[:e.Pos]) + 1
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column(), e.Msg)
+}
+
+// Caret returns the source and a line pointing at the problem:
+//
+//	hp < 30 && ammo >
+//	                 ^ expected operand, got end of expression
+func (e *Error) Caret() string {
+	return e.Source + "\n" + strings.Repeat(" ", e.Column()-1) + "^ " + e.Msg
+}
+
+/*
+ * AST
+ */
+type exprNode interface {
+	pos() int
+}
+
+type logicalNode struct {
+	op       tokenKind // tokAnd or tokOr
+	operands []exprNode
+	at       int
+}
+
+type notNode struct {
+	operand exprNode
+	at      int
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+	at          int
+}
+
+type identNode struct {
+	name string
+	at   int
+}
+
+type literalNode struct {
+	typ   Type
+	value interface{}
+	at    int
+}
+
+func (n *logicalNode) pos() int { return n.at }
+func (n *notNode) pos() int     { return n.at }
+func (n *compareNode) pos() int { return n.at }
+func (n *identNode) pos() int   { return n.at }
+func (n *literalNode) pos() int { return n.at }
+
+/*
+ * parser, by precedence from low to high:
+ *	or      = and { "||" and }
+ *	and     = unary { "&&" unary }
+ *	unary   = "!" unary | compare
+ *	compare = operand [ ("==" | "!=" | "<" | "<=" | ">" | ">=") operand ]
+ *	operand = identifier | number | "-" number | string | "(" or ")"
+ */
+type parser struct {
+	src    string
+	tokens []token
+	next   int
+}
+
+func parse(src string) (exprNode, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	ps := &parser{src: src, tokens: tokens}
+	n, err := ps.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := ps.peek(); t.kind != tokEOF {
+		return nil, errorf(src, t.pos, "unexpected %s", t)
+	}
+	return n, nil
+}
+
+func (ps *parser) peek() token {
+	return ps.tokens[ps.next]
+}
+
+func (ps *parser) advance() token {
+	t := ps.tokens[ps.next]
+	if t.kind != tokEOF {
+		ps.next++
+	}
+	return t
+}
+
+func (ps *parser) parseLogical(op tokenKind, operand func() (exprNode, error)) (exprNode, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if ps.peek().kind != op {
+		return first, nil
+	}
+	n := &logicalNode{op: op, operands: []exprNode{first}, at: first.pos()}
+	for ps.peek().kind == op {
+		ps.advance()
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		n.operands = append(n.operands, next)
+	}
+	return n, nil
+}
+
+func (ps *parser) parseOr() (exprNode, error) {
+	return ps.parseLogical(tokOr, ps.parseAnd)
+}
+
+func (ps *parser) parseAnd() (exprNode, error) {
+	return ps.parseLogical(tokAnd, ps.parseUnary)
+}
+
+func (ps *parser) parseUnary() (exprNode, error) {
+	if t := ps.peek(); t.kind == tokNot {
+		ps.advance()
+		operand, err := ps.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand, t.pos}, nil
+	}
+	return ps.parseCompare()
+}
+
+func (ps *parser) parseCompare() (exprNode, error) {
+	left, err := ps.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	t := ps.peek()
+	if t.kind != tokCompare {
+		return left, nil
+	}
+	ps.advance()
+	right, err := ps.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if next := ps.peek(); next.kind == tokCompare {
+		return nil, errorf(ps.src, next.pos, "comparisons can not be chained, use && or parentheses")
+	}
+	return &compareNode{t.text, left, right, t.pos}, nil
+}
+
+func (ps *parser) parseOperand() (exprNode, error) {
+	t := ps.advance()
+	switch t.kind {
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			return &literalNode{Bool, t.text == "true", t.pos}, nil
+		}
+		return &identNode{t.text, t.pos}, nil
+	case tokInt, tokFloat:
+		return ps.number(t, "")
+	case tokMinus:
+		n := ps.advance()
+		if n.kind != tokInt && n.kind != tokFloat {
+			return nil, errorf(ps.src, n.pos, "expected number after '-', got %s", n)
+		}
+		return ps.number(n, "-")
+	case tokString:
+		return &literalNode{String, t.text, t.pos}, nil
+	case tokLParen:
+		n, err := ps.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := ps.advance(); closing.kind != tokRParen {
+			return nil, errorf(ps.src, closing.pos, "expected ')' to close '(' at column %d, got %s",
+				utf8.RuneCountInString(ps.src[:t.pos])+1, closing)
+		}
+		return n, nil
+	}
+	return nil, errorf(ps.src, t.pos, "expected operand, got %s", t)
+}
+
+func (ps *parser) number(t token, sign string) (exprNode, error) {
+	var n literalNode
+	n.at = t.pos
+	if t.kind == tokInt {
+		v, err := strconv.Atoi(sign + t.text)
+		if err != nil {
+			return nil, errorf(ps.src, t.pos, "integer %s out of range", t.text)
+		}
+		n.typ, n.value = Int, v
+	} else {
+		v, _ := strconv.ParseFloat(sign+t.text, 64)
+		n.typ, n.value = Float, v
+	}
+	return &n, nil
+}
@@ -23,6 +23,12 @@
 
 package precondition
 
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
 //
 type IPrecondition interface {
 	ExternalCondition(input interface{}) bool
@@ -40,6 +46,10 @@ func (Cond *PreconditionTRUE) ExternalCondition(input interface{}) bool {
 	return true
 }
 
+func (Cond *PreconditionTRUE) String() string {
+	return "true"
+}
+
 // always false precondition
 type PreconditionFALSE struct {
 }
@@ -52,6 +62,10 @@ func (Cond *PreconditionFALSE) ExternalCondition(input interface{}) bool {
 	return false
 }
 
+func (Cond *PreconditionFALSE) String() string {
+	return "false"
+}
+
 // return true if both preconditions return true
 type PreconditionAND struct {
 	first  IPrecondition
@@ -63,8 +77,19 @@ func NewPreconditionAND(First IPrecondition, Second IPrecondition) *Precondition
 }
 
 func (Cond *PreconditionAND) ExternalCondition(input interface{}) bool {
-	return Cond.first.ExternalCondition(input) &&
-		Cond.second.ExternalCondition(input)
+	result, _ := Cond.Check(input)
+	return result
+}
+
+func (Cond *PreconditionAND) Check(input interface{}) (bool, error) {
+	if result, err := Check(Cond.first, input); !result || err != nil {
+		return false, err
+	}
+	return Check(Cond.second, input)
+}
+
+func (Cond *PreconditionAND) String() string {
+	return describeCall("and", Cond.first, Cond.second)
 }
 
 // return true if one of the preconditions return true
@@ -78,6 +103,99 @@ func NewPreconditionOR(First IPrecondition, Second IPrecondition) *PreconditionO
 }
 
 func (Cond *PreconditionOR) ExternalCondition(input interface{}) bool {
-	return Cond.first.ExternalCondition(input) ||
-		Cond.second.ExternalCondition(input)
+	result, _ := Cond.Check(input)
+	return result
+}
+
+func (Cond *PreconditionOR) Check(input interface{}) (bool, error) {
+	if result, err := Check(Cond.first, input); result || err != nil {
+		return result, err
+	}
+	return Check(Cond.second, input)
+}
+
+func (Cond *PreconditionOR) String() string {
+	return describeCall("or", Cond.first, Cond.second)
+}
+
+// Describe returns the String of cond if it has one, its type name otherwise
+func Describe(cond IPrecondition) string {
+	if cond == nil {
+		return "nil"
+	}
+	if s, ok := cond.(fmt.Stringer); ok {
+		return s.String()
+	}
+	t := reflect.TypeOf(cond)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// eg. "and(true, false)"
+func describeCall(name string, conds ...IPrecondition) string {
+	args := make([]string, len(conds))
+	for i, cond := range conds {
+		args[i] = Describe(cond)
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// preconditions holding state implement IPreconditionCloner, so that cloned
+// trees get their own copy, the others are shared by the original and the clone
+type IPreconditionCloner interface {
+	ClonePrecondition() IPrecondition
+}
+
+// ClonePrecondition copies cond if it or one of its operands is an IPreconditionCloner
+func ClonePrecondition(cond IPrecondition) IPrecondition {
+	clone, _ := clonePrecondition(cond)
+	return clone
+}
+
+// return the clone and whether it is a copy
+func clonePrecondition(cond IPrecondition) (IPrecondition, bool) {
+	switch Cond := cond.(type) {
+	case IPreconditionCloner:
+		return Cond.ClonePrecondition(), true
+	case *PreconditionAND:
+		first, copied1 := clonePrecondition(Cond.first)
+		second, copied2 := clonePrecondition(Cond.second)
+		if copied1 || copied2 {
+			return NewPreconditionAND(first, second), true
+		}
+	case *PreconditionOR:
+		first, copied1 := clonePrecondition(Cond.first)
+		second, copied2 := clonePrecondition(Cond.second)
+		if copied1 || copied2 {
+			return NewPreconditionOR(first, second), true
+		}
+	case *PreconditionNOT:
+		if operand, copied := clonePrecondition(Cond.operand); copied {
+			return Not(operand), true
+		}
+	case *PreconditionXOR:
+		first, copied1 := clonePrecondition(Cond.first)
+		second, copied2 := clonePrecondition(Cond.second)
+		if copied1 || copied2 {
+			return Xor(first, second), true
+		}
+	case *PreconditionCount:
+		if operands, copied := clonePreconditions(Cond.operands); copied {
+			return &PreconditionCount{Cond.name, Cond.min, Cond.max, operands}, true
+		}
+	}
+	return cond, false
+}
+
+func clonePreconditions(conds []IPrecondition) ([]IPrecondition, bool) {
+	clones := make([]IPrecondition, len(conds))
+	copied := false
+	for i, cond := range conds {
+		var c bool
+		clones[i], c = clonePrecondition(cond)
+		copied = copied || c
+	}
+	return clones, copied
 }
@@ -24,8 +24,10 @@
 package precondition
 
 import (
-	. "github.com/smartystreets/goconvey/convey"
+	"errors"
 	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestPrecondition(t *testing.T) {
@@ -98,3 +100,240 @@ func TestAndOrCond(t *testing.T) {
 		})
 	})
 }
+
+type countCond struct {
+	count int
+}
+
+func (cond *countCond) ExternalCondition(input interface{}) bool {
+	cond.count++
+	return true
+}
+
+func (cond *countCond) ClonePrecondition() IPrecondition {
+	return &countCond{cond.count}
+}
+
+func TestClonePrecondition(t *testing.T) {
+	trueCond := NewPreconditionTRUE()
+
+	Convey("Stateless preconditions are shared", t, func() {
+		cond := NewPreconditionAND(trueCond, NewPreconditionOR(trueCond, trueCond))
+		So(ClonePrecondition(cond), ShouldEqual, cond)
+	})
+
+	Convey("Preconditions holding a cloner are copied", t, func() {
+		counter := &countCond{}
+		cond := NewPreconditionOR(NewPreconditionFALSE(), NewPreconditionAND(trueCond, counter))
+		clone := ClonePrecondition(cond)
+		So(clone, ShouldNotEqual, cond)
+		So(clone.ExternalCondition(nil), ShouldBeTrue)
+		So(counter.count, ShouldEqual, 0)
+	})
+}
+
+// counts its evaluations and returns result
+type spyCond struct {
+	result bool
+	calls  int
+}
+
+func (cond *spyCond) ExternalCondition(input interface{}) bool {
+	cond.calls++
+	return cond.result
+}
+
+func (cond *spyCond) String() string {
+	if cond.result {
+		return "yes"
+	}
+	return "no"
+}
+
+func TestCombinators(t *testing.T) {
+	yes := func() *spyCond { return &spyCond{result: true} }
+	no := func() *spyCond { return &spyCond{result: false} }
+
+	Convey("Not and Xor", t, func() {
+		So(Not(yes()).ExternalCondition(nil), ShouldBeFalse)
+		So(Not(no()).ExternalCondition(nil), ShouldBeTrue)
+		So(Xor(yes(), no()).ExternalCondition(nil), ShouldBeTrue)
+		So(Xor(yes(), yes()).ExternalCondition(nil), ShouldBeFalse)
+		So(Xor(no(), no()).ExternalCondition(nil), ShouldBeFalse)
+	})
+
+	Convey("All, Any and None", t, func() {
+		So(All().ExternalCondition(nil), ShouldBeTrue)
+		So(All(yes(), yes(), yes()).ExternalCondition(nil), ShouldBeTrue)
+		So(All(yes(), no(), yes()).ExternalCondition(nil), ShouldBeFalse)
+		So(Any().ExternalCondition(nil), ShouldBeFalse)
+		So(Any(no(), yes()).ExternalCondition(nil), ShouldBeTrue)
+		So(Any(no(), no()).ExternalCondition(nil), ShouldBeFalse)
+		So(None().ExternalCondition(nil), ShouldBeTrue)
+		So(None(no(), no()).ExternalCondition(nil), ShouldBeTrue)
+		So(None(no(), yes()).ExternalCondition(nil), ShouldBeFalse)
+	})
+
+	Convey("AtLeast", t, func() {
+		So(AtLeast(2, yes(), no(), yes()).ExternalCondition(nil), ShouldBeTrue)
+		So(AtLeast(2, yes(), no(), no()).ExternalCondition(nil), ShouldBeFalse)
+		So(AtLeast(0).ExternalCondition(nil), ShouldBeTrue)
+	})
+
+	Convey("Evaluation stops once the result is known", t, func() {
+		last := yes()
+		So(All(no(), last).ExternalCondition(nil), ShouldBeFalse)
+		So(Any(yes(), last).ExternalCondition(nil), ShouldBeTrue)
+		So(None(yes(), last).ExternalCondition(nil), ShouldBeFalse)
+		So(AtLeast(2, yes(), yes(), last).ExternalCondition(nil), ShouldBeTrue)
+		So(AtLeast(2, no(), no(), last).ExternalCondition(nil), ShouldBeFalse)
+		So(last.calls, ShouldEqual, 0)
+	})
+
+	Convey("Combinators have a readable String", t, func() {
+		So(Describe(NewPreconditionAND(Not(yes()), NewPreconditionOR(no(), NewPreconditionTRUE()))), ShouldEqual, "and(not(yes), or(no, true))")
+		So(Describe(Xor(yes(), All(no(), Any(yes())))), ShouldEqual, "xor(yes, all(no, any(yes)))")
+		So(Describe(AtLeast(2, yes(), None(no()), &countCond{})), ShouldEqual, "atLeast(2, yes, none(no), countCond)")
+		So(Describe(nil), ShouldEqual, "nil")
+	})
+
+	Convey("Combinators holding a cloner are copied", t, func() {
+		counter := &countCond{}
+		cond := AtLeast(1, Not(NewPreconditionTRUE()), Xor(NewPreconditionFALSE(), counter))
+		shared := Any(Not(NewPreconditionTRUE()))
+		So(ClonePrecondition(shared), ShouldEqual, shared)
+		clone := ClonePrecondition(cond)
+		So(clone, ShouldNotEqual, cond)
+		So(clone.ExternalCondition(nil), ShouldBeTrue)
+		So(counter.count, ShouldEqual, 0)
+		So(Describe(clone), ShouldEqual, Describe(cond))
+	})
+}
+
+func TestCache(t *testing.T) {
+	Convey("Results are kept for one frame", t, func() {
+		cache := NewCache()
+		cache.SetFrame(0)
+		spy := &spyCond{result: true}
+		cached := cache.Wrap(spy)
+		cond := All(cached, Any(NewPreconditionFALSE(), cached))
+
+		So(cond.ExternalCondition(nil), ShouldBeTrue)
+		So(spy.calls, ShouldEqual, 1)
+		So(cache.Evaluate(spy, nil), ShouldBeTrue)
+		So(spy.calls, ShouldEqual, 1)
+
+		spy.result = false
+		cache.SetFrame(0)
+		So(cond.ExternalCondition(nil), ShouldBeTrue)
+		cache.Invalidate(spy)
+		So(cond.ExternalCondition(nil), ShouldBeFalse)
+		So(spy.calls, ShouldEqual, 2)
+
+		spy.result = true
+		cache.SetFrame(1)
+		So(cond.ExternalCondition(nil), ShouldBeTrue)
+		cache.InvalidateAll()
+		So(cached.ExternalCondition(nil), ShouldBeTrue)
+		So(spy.calls, ShouldEqual, 4)
+
+		hits, misses := cache.Stats()
+		So(hits, ShouldEqual, 5)
+		So(misses, ShouldEqual, 4)
+		So(Describe(cached), ShouldEqual, "yes")
+	})
+}
+
+func TestCheck(t *testing.T) {
+	failure := errors.New("no target")
+	broken := Checked("target", func(input interface{}) (bool, error) {
+		return true, failure
+	})
+	yes := NewPreconditionTRUE()
+
+	Convey("Checked adapts functions returning errors", t, func() {
+		So(broken.ExternalCondition(nil), ShouldBeFalse)
+		result, err := Check(broken, nil)
+		So(result, ShouldBeFalse)
+		So(err.Error(), ShouldEqual, "precondition target: no target")
+		So(errors.Is(err, failure), ShouldBeTrue)
+
+		result, err = Check(yes, nil)
+		So(result, ShouldBeTrue)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Combinators are false with the error of an operand", t, func() {
+		for _, cond := range []IPrecondition{
+			NewPreconditionAND(yes, broken),
+			NewPreconditionOR(NewPreconditionFALSE(), broken),
+			Not(broken),
+			Xor(NewPreconditionFALSE(), broken),
+			All(yes, broken),
+			Any(NewPreconditionFALSE(), broken),
+			None(broken),
+			AtLeast(2, yes, broken),
+			NewCache().Wrap(broken),
+		} {
+			result, err := Check(cond, nil)
+			So(result, ShouldBeFalse)
+			So(errors.Is(err, failure), ShouldBeTrue)
+			So(cond.ExternalCondition(nil), ShouldBeFalse)
+		}
+
+		// operands which are not evaluated do not report errors
+		result, err := Check(NewPreconditionOR(yes, broken), nil)
+		So(result, ShouldBeTrue)
+		So(err, ShouldBeNil)
+		result, err = Check(Any(yes, broken), nil)
+		So(result, ShouldBeTrue)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Errors are cached with the results", t, func() {
+		calls := 0
+		cond := Checked("counted", func(input interface{}) (bool, error) {
+			calls++
+			return false, failure
+		})
+		cache := NewCache()
+		cache.SetFrame(0)
+		_, err1 := cache.Check(cond, nil)
+		_, err2 := cache.Check(cond, nil)
+		So(err1, ShouldNotBeNil)
+		So(err2, ShouldEqual, err1)
+		So(calls, ShouldEqual, 1)
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	Convey("Sub-expressions are evaluated like the precondition", t, func() {
+		cond := NewPreconditionOR(
+			NewPreconditionAND(NewPreconditionFALSE(), NewPreconditionTRUE()),
+			Not(AtLeast(1, NewPreconditionTRUE(), NewPreconditionFALSE())))
+		e := Evaluate(cond, nil)
+		So(e.Value, ShouldEqual, cond.ExternalCondition(nil))
+		So(e.Expr, ShouldEqual, "or(and(false, true), not(atLeast(1, true, false)))")
+
+		and, not := e.Operands[0], e.Operands[1]
+		So(and.Value, ShouldBeFalse)
+		So(and.Operands[0].Value, ShouldBeFalse)
+		So(and.Operands[1].Skipped, ShouldBeTrue)
+		So(not.Value, ShouldBeFalse)
+		count := not.Operands[0]
+		So(count.Value, ShouldBeTrue)
+		So(count.Operands[0].Value, ShouldBeTrue)
+		So(count.Operands[1].Skipped, ShouldBeTrue)
+	})
+
+	Convey("Errors are recorded where they happen", t, func() {
+		broken := Checked("target", func(input interface{}) (bool, error) {
+			return false, errors.New("no target")
+		})
+		e := Evaluate(All(NewPreconditionTRUE(), Not(broken), NewPreconditionTRUE()), nil)
+		So(e.Value, ShouldBeFalse)
+		So(e.Error, ShouldEqual, "precondition target: no target")
+		So(e.Operands[1].Operands[0].Error, ShouldEqual, e.Error)
+		So(e.Operands[2].Skipped, ShouldBeTrue)
+	})
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 	_ "math/rand"
+	"os"
 	"time"
 
 	btboard "github.com/ShionRyuu/gobevtree/blackboard"
+	bt "github.com/ShionRyuu/gobevtree/builder"
 	btnode "github.com/ShionRyuu/gobevtree/node"
 	btcond "github.com/ShionRyuu/gobevtree/precondition"
 )
@@ -29,6 +32,10 @@ func (this *TestTerNode) Exit(input interface{}, exitStatus btnode.BevRunningSta
 	fmt.Println("Exit node", this.data)
 }
 
+func (this *TestTerNode) CloneNode() btnode.IBevNode {
+	return &TestTerNode{btnode.NewTerminalNode(nil, nil), this.data}
+}
+
 //wait action
 const (
 	delayTimeFrame = 1
@@ -64,29 +71,8 @@ func (this *WaitActNode) Exit(input interface{}, exitStatus btnode.BevRunningSta
 	this.useTime = 0
 }
 
-//通过blackboard比较int条件
-type PreconditionLessInt struct {
-	first  int //变量索引
-	second int //变量索引
-}
-
-func NewPreconditionLessInt(FirstIndex int, SecIndex int) *PreconditionLessInt {
-	return &PreconditionLessInt{first: FirstIndex, second: SecIndex}
-}
-
-func (Cond *PreconditionLessInt) ExternalCondition(input interface{}) bool {
-	board := input.(*btboard.BlackBoard)
-	a, erra := board.GetValueAsInt(Cond.first)
-	if erra != nil {
-		fmt.Errorf(erra.Error())
-	}
-
-	b, errb := board.GetValueAsInt(Cond.second)
-	if errb != nil {
-		fmt.Errorf(errb.Error())
-	}
-
-	return a < b
+func (this *WaitActNode) CloneNode() btnode.IBevNode {
+	return &WaitActNode{btnode.NewTerminalNode(nil, nil), this.waitTime, this.useTime}
 }
 
 func main() {
@@ -144,7 +130,7 @@ func testPrioritySelector() {
 	inboard.SetValueAsInt(indexB, 10) //设置2号变量
 
 	tree := btnode.NewPrioritySelector(nil, nil)
-	node1 := &TestTerNode{btnode.NewTerminalNode(nil, NewPreconditionLessInt(indexA, indexB)), "node1"}
+	node1 := &TestTerNode{btnode.NewTerminalNode(nil, btcond.CompareIntKeys(indexA, btcond.OpLess, indexB)), "node1"}
 	node2 := &TestTerNode{btnode.NewTerminalNode(nil, btcond.NewPreconditionTRUE()), "node2"}
 	wrap1 := btnode.NewTerminal(node1)
 	wrap2 := btnode.NewTerminal(node2)
@@ -170,7 +156,7 @@ func testRandomSelector() {
 
 }
 func renderTree(tree btnode.IBevNode, count int, inboard *btboard.BlackBoard, outboard *btboard.BlackBoard, delayTime int) {
-	btnode.PrintbevTree(tree, 0)
+	btnode.RenderTree(os.Stdout, tree, btnode.RenderOptions{Preconditions: true})
 	for i := 0; i < count; i++ {
 		if tree.Evaluate(inboard) {
 			tree.Tick(inboard, outboard)
@@ -194,8 +180,10 @@ func testSimple() {
 	*
 	 */
 	//注意：只有经过wrapper封装，调用NewSelector，NewTerminal才会在执行precondition条件判断
+	//builder会自动封装
 	//结果：在seq下执行10次，再到右侧ran执行10次
 	fmt.Println("testSimple===========>")
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	inboard := btboard.NewBlackboard()
 	outboard := btboard.NewBlackboard()
 	indexA := 1
@@ -204,37 +192,27 @@ func testSimple() {
 	inboard.SetValueAsInt(indexA, 0)  //设置1号变量
 	inboard.SetValueAsInt(indexB, 10) //设置2号变量
 
-	tree := btnode.NewPrioritySelector(nil, nil)
-	cond := NewPreconditionLessInt(indexA, indexB)
-	seq := btnode.NewSelector(btnode.NewSequenceSelector(nil, cond))
-	randn := btnode.NewSelector(btnode.NewRandomSelector(nil, btcond.NewPreconditionTRUE()))
-	tree.AddChildNode(seq)
-	tree.AddChildNode(randn)
-
-	node11 := &TestTerNode{btnode.NewTerminalNode(seq, nil), "node11"}
-	node12 := &TestTerNode{btnode.NewTerminalNode(seq, nil), "node12"}
-	node21 := &TestTerNode{btnode.NewTerminalNode(randn, nil), "node21"}
-	node22 := &TestTerNode{btnode.NewTerminalNode(randn, nil), "node22"}
-	waitAct := NewWaitActNode(5, seq)
-
-	wrap11 := btnode.NewTerminal(node11)
-	wrapWait := btnode.NewTerminal(waitAct)
-	wrap12 := btnode.NewTerminal(node12)
-	wrap21 := btnode.NewTerminal(node21)
-	wrap22 := btnode.NewTerminal(node22)
-	seq.AddChildNode(wrap11)
-	seq.AddChildNode(wrapWait)
-	seq.AddChildNode(wrap12)
-
-	randn.AddChildNode(wrap21)
-	randn.AddChildNode(wrap22)
-	btnode.PrintbevTree(tree, 0)
-	//renderTree
+	//builder设置父节点，并用NewSelector，NewTerminal封装节点
+	root, err := bt.Priority("root").
+		Child(bt.Sequence("seq").When(btcond.CompareIntKeys(indexA, btcond.OpLess, indexB)).
+			Leaf(&TestTerNode{btnode.NewTerminalNode(nil, nil), "node11"}).
+			Leaf(NewWaitActNode(5, nil)).
+			Leaf(&TestTerNode{btnode.NewTerminalNode(nil, nil), "node12"})).
+		Child(bt.Random("rand").When(btcond.NewPreconditionTRUE()).
+			Leaf(&TestTerNode{btnode.NewTerminalNode(nil, nil), "node21"}).
+			Leaf(&TestTerNode{btnode.NewTerminalNode(nil, nil), "node22"})).
+		Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	btnode.RenderTree(os.Stdout, root, btnode.RenderOptions{Preconditions: true})
+	//分支切换时输出日志
+	tree := btnode.NewBevTree(root).SetLogger(logger, btnode.LogOptions{Agent: "simple"})
+	inboard.SetLogger(tree.BoardLogger())
 	for i := 0; i < 20; i++ {
 		//one frame
-		if tree.Evaluate(inboard) {
-			tree.Tick(inboard, outboard)
-		}
+		tree.Update(inboard, outboard)
 		fmt.Println("frame:", i)
 		time.Sleep(time.Duration(delayTimeFrame) * time.Second)
 		inboard.SetValueAsInt(indexA, i) //设置1号变量
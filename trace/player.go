@@ -0,0 +1,211 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package trace
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ShionRyuu/gobevtree/node"
+)
+
+// NodeState is what a node did during one frame
+type NodeState struct {
+	Evaluated    bool
+	Result       bool
+	Ticked       bool
+	Status       node.BevRunningStatus
+	Entered      bool
+	Exited       bool
+	ExitStatus   node.BevRunningStatus
+	Transitioned bool
+}
+
+func (state NodeState) String() string {
+	var parts []string
+	if state.Evaluated {
+		parts = append(parts, fmt.Sprintf("evaluate=%v", state.Result))
+	}
+	if state.Entered {
+		parts = append(parts, "enter")
+	}
+	if state.Ticked {
+		parts = append(parts, "tick="+state.Status.String())
+	}
+	if state.Exited {
+		parts = append(parts, "exit="+state.ExitStatus.String())
+	}
+	if state.Transitioned {
+		parts = append(parts, "transition")
+	}
+	return strings.Join(parts, " ")
+}
+
+/*
+ * Player steps through the frames of a trace
+ */
+type Player struct {
+	trace    *Trace
+	pos      int
+	children map[int][]int
+	board    map[int]interface{}
+}
+
+func NewPlayer(t *Trace) *Player {
+	p := &Player{trace: t, children: map[int][]int{}}
+	for _, info := range t.Nodes {
+		p.children[info.Parent] = append(p.children[info.Parent], info.Id)
+	}
+	p.Seek(0)
+	return p
+}
+
+func (p *Player) Len() int {
+	return len(p.trace.Frames)
+}
+
+// position of the current frame in the trace
+func (p *Player) Pos() int {
+	return p.pos
+}
+
+func (p *Player) Frame() *Frame {
+	if p.pos < 0 || p.pos >= len(p.trace.Frames) {
+		return nil
+	}
+	return p.trace.Frames[p.pos]
+}
+
+func (p *Player) Next() bool {
+	return p.Seek(p.pos + 1)
+}
+
+func (p *Player) Prev() bool {
+	return p.Seek(p.pos - 1)
+}
+
+// Seek moves to the frame at pos, false if there is no such frame
+func (p *Player) Seek(pos int) bool {
+	if pos < 0 || pos >= len(p.trace.Frames) {
+		return false
+	}
+	start := 0
+	if pos > p.pos && p.board != nil {
+		start = p.pos + 1
+	} else {
+		p.board = map[int]interface{}{}
+	}
+	for _, frame := range p.trace.Frames[start : pos+1] {
+		for _, delta := range frame.Board {
+			if delta.Deleted {
+				delete(p.board, delta.Key)
+			} else {
+				p.board[delta.Key] = delta.Value
+			}
+		}
+	}
+	p.pos = pos
+	return true
+}
+
+// blackboard contents at the end of the current frame
+func (p *Player) Blackboard() map[int]interface{} {
+	board := make(map[int]interface{}, len(p.board))
+	for k, v := range p.board {
+		board[k] = v
+	}
+	return board
+}
+
+// what every node did during the current frame, keyed by node id
+func (p *Player) NodeStates() map[int]NodeState {
+	states := map[int]NodeState{}
+	frame := p.Frame()
+	if frame == nil {
+		return states
+	}
+	for _, event := range frame.Events {
+		state := states[event.Node]
+		switch event.Kind {
+		case EventEvaluate:
+			state.Evaluated, state.Result = true, event.Value != 0
+		case EventEnter:
+			state.Entered = true
+		case EventTick:
+			state.Ticked, state.Status = true, node.BevRunningStatus(event.Value)
+		case EventExit:
+			state.Exited, state.ExitStatus = true, node.BevRunningStatus(event.Value)
+		case EventTransition:
+			state.Transitioned = true
+		}
+		states[event.Node] = state
+	}
+	return states
+}
+
+// Render prints the tree annotated with the current frame and the blackboard, keys changed by the frame are marked with "*"
+func (p *Player) Render(w io.Writer) {
+	frame := p.Frame()
+	if frame == nil {
+		fmt.Fprintln(w, "no frame")
+		return
+	}
+
+	fmt.Fprintf(w, "frame %d (%d/%d)\n", frame.Index, p.pos+1, p.Len())
+	states := p.NodeStates()
+	var render func(id int)
+	render = func(id int) {
+		info := p.trace.Nodes[id]
+		line := fmt.Sprintf("%s|— %s", strings.Repeat("    ", info.Depth), info.Label)
+		if state, ok := states[id]; ok {
+			line += " [" + state.String() + "]"
+		}
+		fmt.Fprintln(w, line)
+		for _, child := range p.children[id] {
+			render(child)
+		}
+	}
+	for _, root := range p.children[-1] {
+		render(root)
+	}
+
+	keys := make([]int, 0, len(p.board))
+	for k := range p.board {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	changed := map[int]bool{}
+	for _, delta := range frame.Board {
+		changed[delta.Key] = true
+	}
+	for _, k := range keys {
+		mark := " "
+		if changed[k] {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "%s [%d] = %v\n", mark, k, p.board[k])
+	}
+}
@@ -0,0 +1,248 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package trace
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	"github.com/ShionRyuu/gobevtree/node"
+)
+
+/*
+ * Recorder listens to a tree and writes one Frame per EndFrame
+ */
+type Recorder struct {
+	node.BevListener
+	tree    *node.BevTree
+	enc     *gob.Encoder
+	ids     map[node.IBevNode]int
+	nodes   []NodeInfo
+	pending []NodeInfo
+	events  []Event
+	board   *bb.BlackBoard
+	values  map[int]interface{}
+	err     error
+}
+
+func NewRecorder(w io.Writer, tree *node.BevTree) *Recorder {
+	r := &Recorder{tree: tree, enc: gob.NewEncoder(w), ids: map[node.IBevNode]int{}}
+	r.index()
+	tree.AddListener(r)
+	return r
+}
+
+// record the changes of board with every frame
+func (r *Recorder) WatchBlackboard(board *bb.BlackBoard) *Recorder {
+	r.board = board
+	r.values = map[int]interface{}{}
+	return r
+}
+
+// Update the tree and end the frame, the error is the one of EndFrame
+func (r *Recorder) Update(input interface{}, output interface{}) (node.BevRunningStatus, error) {
+	status := r.tree.Update(input, output)
+	return status, r.EndFrame()
+}
+
+// EndFrame writes the events since the last call as the frame just updated
+func (r *Recorder) EndFrame() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	frame := &Frame{Index: r.tree.GetFrame() - 1, Nodes: r.pending, Events: r.events, Board: r.boardDeltas()}
+	r.pending, r.events = nil, nil
+	r.err = r.enc.Encode(frame)
+	return r.err
+}
+
+// stop listening to the tree
+func (r *Recorder) Stop() error {
+	r.tree.RemoveListener(r)
+	return r.err
+}
+
+// add the nodes not indexed yet
+func (r *Recorder) index() {
+	var parents []int
+	node.Walk(r.tree.GetRoot(), func(n node.IBevNode, path string, depth int) {
+		parents = parents[:depth]
+		parent := -1
+		if depth > 0 {
+			parent = parents[depth-1]
+		}
+		id, ok := r.ids[n]
+		if !ok {
+			id = len(r.nodes)
+			info := NodeInfo{id, parent, depth, path, node.NodeLabel(n), node.NodeTypeName(n)}
+			r.ids[n] = id
+			r.nodes = append(r.nodes, info)
+			r.pending = append(r.pending, info)
+		}
+		parents = append(parents, id)
+	})
+}
+
+func (r *Recorder) record(n node.IBevNode, kind EventKind, value int) {
+	id, ok := r.ids[n]
+	if !ok {
+		r.index()
+		if id, ok = r.ids[n]; !ok {
+			return
+		}
+	}
+	r.events = append(r.events, Event{id, kind, value})
+}
+
+func (r *Recorder) OnEvaluate(n node.IBevNode, result bool) {
+	value := 0
+	if result {
+		value = 1
+	}
+	r.record(n, EventEvaluate, value)
+}
+
+func (r *Recorder) OnEnter(n node.IBevNode) {
+	r.record(n, EventEnter, 0)
+}
+
+func (r *Recorder) OnTick(n node.IBevNode, status node.BevRunningStatus) {
+	r.record(n, EventTick, int(status))
+}
+
+func (r *Recorder) OnExit(n node.IBevNode, status node.BevRunningStatus) {
+	r.record(n, EventExit, int(status))
+}
+
+func (r *Recorder) OnTransition(n node.IBevNode) {
+	r.record(n, EventTransition, 0)
+}
+
+func (r *Recorder) boardDeltas() []Delta {
+	if r.board == nil {
+		return nil
+	}
+
+	var deltas []Delta
+	seen := map[int]bool{}
+	r.board.Range(func(key int, value interface{}) bool {
+		seen[key] = true
+		if last, ok := r.values[key]; !ok || !reflect.DeepEqual(last, value) {
+			// a copy, so that maps, slices and pointers changed in place are seen
+			r.values[key] = snapshot(value)
+			deltas = append(deltas, Delta{Key: key, Value: encodable(value)})
+		}
+		return true
+	})
+	for key := range r.values {
+		if !seen[key] {
+			delete(r.values, key)
+			deltas = append(deltas, Delta{Key: key, Deleted: true})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Key < deltas[j].Key })
+	return deltas
+}
+
+// values gob knows without registration are kept, others are recorded as text
+func encodable(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, string, []byte:
+		return value
+	}
+	return Opaque(fmt.Sprintf("%T(%v)", value, value))
+}
+
+// deep copy of value, unexported fields of structs are copied shallowly
+func snapshot(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(value), map[uintptr]reflect.Value{}).Interface()
+}
+
+// copies stores the copy of every pointer already copied, for cycles
+func deepCopy(v reflect.Value, copies map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if c, ok := copies[v.Pointer()]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		copies[v.Pointer()] = c
+		c.Elem().Set(deepCopy(v.Elem(), copies))
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value(), copies))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), copies))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), copies))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i), copies))
+			}
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem(), copies))
+		return c
+	}
+	return v
+}
@@ -0,0 +1,138 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+/*
+ * Package trace records what a tree does frame by frame into a compact gob
+ * stream, and replays the recording forwards and backwards.
+ */
+package trace
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+/*
+ * EventKind
+ */
+const (
+	EventEvaluate EventKind = iota
+	EventEnter
+	EventTick
+	EventExit
+	EventTransition
+)
+
+type EventKind uint8
+
+func (kind EventKind) String() string {
+	switch kind {
+	case EventEvaluate:
+		return "evaluate"
+	case EventEnter:
+		return "enter"
+	case EventTick:
+		return "tick"
+	case EventExit:
+		return "exit"
+	case EventTransition:
+		return "transition"
+	}
+	return fmt.Sprintf("event(%d)", uint8(kind))
+}
+
+// NodeInfo describes a node of the recorded tree, Parent is -1 for the root
+type NodeInfo struct {
+	Id     int
+	Parent int
+	Depth  int
+	Path   string
+	Label  string
+	Type   string
+}
+
+// Event of a node, Value is 0 or 1 for evaluate and the running status for tick and exit
+type Event struct {
+	Node  int
+	Kind  EventKind
+	Value int
+}
+
+// Delta is a blackboard key set to Value, or deleted
+type Delta struct {
+	Key     int
+	Value   interface{}
+	Deleted bool
+}
+
+// Opaque is the text of a blackboard value gob can not encode
+type Opaque string
+
+func init() {
+	gob.Register(Opaque(""))
+}
+
+// Frame holds what happened during one Update, and the nodes seen for the first time
+type Frame struct {
+	Index  int
+	Nodes  []NodeInfo
+	Events []Event
+	Board  []Delta
+}
+
+// Trace is a loaded recording
+type Trace struct {
+	Nodes  []NodeInfo
+	Frames []*Frame
+}
+
+func Read(r io.Reader) (*Trace, error) {
+	dec := gob.NewDecoder(r)
+	t := &Trace{}
+	for {
+		frame := &Frame{}
+		if err := dec.Decode(frame); err == io.EOF {
+			return t, nil
+		} else if err != nil {
+			return nil, err
+		}
+		t.Nodes = append(t.Nodes, frame.Nodes...)
+		t.Frames = append(t.Frames, frame)
+	}
+}
+
+func ReadFile(path string) (*Trace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	bt "github.com/ShionRyuu/gobevtree/builder"
+	"github.com/ShionRyuu/gobevtree/node"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type counter struct {
+	*node.TerminalNode
+}
+
+func (this *counter) Enter(input interface{}) {
+}
+
+func (this *counter) Execute(input interface{}, output interface{}) node.BevRunningStatus {
+	board := input.(*bb.BlackBoard)
+	n, _ := board.GetValueAsInt(1)
+	board.SetValueAsInt(1, n+1)
+	if n%2 == 0 {
+		return node.StateExecuting
+	}
+	return node.StateFinish
+}
+
+func (this *counter) Exit(input interface{}, exitStatus node.BevRunningStatus) {
+}
+
+func TestRecordReplay(t *testing.T) {
+	Convey("A recorded run can be replayed in both directions", t, func() {
+		root, err := bt.Sequence("root").Child(bt.Terminal("count", &counter{node.NewTerminalNode(nil, nil)})).Build()
+		So(err, ShouldBeNil)
+		board := bb.NewBlackboard()
+		board.SetValueAsString(2, "constant")
+
+		var buf bytes.Buffer
+		tree := node.NewBevTree(root)
+		recorder := NewRecorder(&buf, tree).WatchBlackboard(board)
+		for i := 0; i < 3; i++ {
+			_, err := recorder.Update(board, nil)
+			So(err, ShouldBeNil)
+		}
+		So(recorder.Stop(), ShouldBeNil)
+
+		trace, err := Read(&buf)
+		So(err, ShouldBeNil)
+		So(len(trace.Frames), ShouldEqual, 3)
+		So(len(trace.Nodes), ShouldEqual, 2)
+		So(trace.Nodes[1].Path, ShouldEqual, "root/count")
+		So(trace.Frames[1].Board, ShouldResemble, []Delta{{Key: 1, Value: 2}})
+
+		player := NewPlayer(trace)
+		So(player.NodeStates()[1], ShouldResemble, NodeState{Evaluated: true, Result: true, Entered: true, Ticked: true, Status: node.StateExecuting})
+		So(player.Next(), ShouldBeTrue)
+		So(player.NodeStates()[1].Exited, ShouldBeTrue)
+		So(player.Seek(2), ShouldBeTrue)
+		So(player.Next(), ShouldBeFalse)
+		So(player.Blackboard(), ShouldResemble, map[int]interface{}{1: 3, 2: "constant"})
+		So(player.Prev(), ShouldBeTrue)
+		So(player.Prev(), ShouldBeTrue)
+		So(player.Blackboard(), ShouldResemble, map[int]interface{}{1: 1, 2: "constant"})
+
+		var out bytes.Buffer
+		player.Render(&out)
+		So(strings.Split(out.String(), "\n"), ShouldResemble, []string{
+			"frame 0 (1/3)",
+			"|— root [evaluate=true tick=executing]",
+			"    |— count [evaluate=true enter tick=executing]",
+			"* [1] = 1",
+			"* [2] = constant",
+			"",
+		})
+	})
+}
+
+type failingWriter struct{}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+type cell struct {
+	Value *int
+}
+
+func TestRecorderChanges(t *testing.T) {
+	Convey("Values changed in place are recorded", t, func() {
+		root, err := bt.Sequence("root").Child(bt.Terminal("count", &counter{node.NewTerminalNode(nil, nil)})).Build()
+		So(err, ShouldBeNil)
+		items := map[string]int{"arrows": 10}
+		n := 1
+		board := bb.NewBlackboard()
+		board.SetValueAsInterface(3, items)
+		board.SetValueAsInterface(4, &cell{&n})
+
+		var buf bytes.Buffer
+		recorder := NewRecorder(&buf, node.NewBevTree(root)).WatchBlackboard(board)
+		recorder.Update(board, nil)
+		items["arrows"] = 9
+		n = 2
+		recorder.Update(board, nil)
+		recorder.Update(board, nil)
+
+		trace, err := Read(&buf)
+		So(err, ShouldBeNil)
+		So(trace.Frames[1].Board, ShouldHaveLength, 3)
+		So(trace.Frames[1].Board[1].Value, ShouldEqual, Opaque("map[string]int(map[arrows:9])"))
+		So(trace.Frames[1].Board[2].Key, ShouldEqual, 4)
+		So(trace.Frames[2].Board, ShouldHaveLength, 1)
+	})
+
+	Convey("Errors of the writer are returned by Update", t, func() {
+		root, err := bt.Sequence("root").Child(bt.Terminal("count", &counter{node.NewTerminalNode(nil, nil)})).Build()
+		So(err, ShouldBeNil)
+		recorder := NewRecorder(failingWriter{}, node.NewBevTree(root))
+		_, err = recorder.Update(bb.NewBlackboard(), nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "disk full")
+		So(recorder.Stop(), ShouldEqual, err)
+	})
+}
@@ -0,0 +1,138 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+/*
+ * Package utility has response curves and scorers for node.UtilitySelector:
+ *
+ *	hunger := utility.Key(1, 0, 100, utility.Power(2)) // hungrier, eat sooner
+ *	danger := utility.Key(2, 0, 10, utility.Logistic(10, 0.5))
+ *	root := bt.Utility("root", node.UtilityHighest, 0.1).
+ *		Child(bt.Terminal("eat", eat).Score(hunger)).
+ *		Child(bt.Terminal("flee", flee).Score(danger))
+ */
+package utility
+
+import (
+	"math"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	"github.com/ShionRyuu/gobevtree/node"
+)
+
+// Curve maps x, usually in [0, 1], to a score
+type Curve func(x float64) float64
+
+// slope*x + intercept
+func Linear(slope float64, intercept float64) Curve {
+	return func(x float64) float64 {
+		return slope*x + intercept
+	}
+}
+
+// x^exponent, slow start for exponent > 1 and fast start for exponent < 1
+func Power(exponent float64) Curve {
+	return func(x float64) float64 {
+		return math.Pow(x, exponent)
+	}
+}
+
+// S shaped from 0 to 1, centered on midpoint
+func Logistic(steepness float64, midpoint float64) Curve {
+	return func(x float64) float64 {
+		return 1 / (1 + math.Exp(-steepness*(x-midpoint)))
+	}
+}
+
+// 1 - curve(x)
+func Inverse(curve Curve) Curve {
+	return func(x float64) float64 {
+		return 1 - curve(x)
+	}
+}
+
+// 0 below threshold and 1 from it
+func Step(threshold float64) Curve {
+	return func(x float64) float64 {
+		if x < threshold {
+			return 0
+		}
+		return 1
+	}
+}
+
+// curve limited to [min, max]
+func Clamp(curve Curve, min float64, max float64) Curve {
+	return func(x float64) float64 {
+		return math.Max(min, math.Min(max, curve(x)))
+	}
+}
+
+/*
+ * Key scores the value of key on the blackboard input, mapped from [min, max]
+ * to [0, 1] and clamped, then passed to curve. Int, float32 and float64 values
+ * are accepted, the score is 0 when the key is missing or holds another type.
+ */
+func Key(key int, min float64, max float64, curve Curve) node.ScorerFunc {
+	return func(input interface{}) float64 {
+		board, ok := input.(*bb.BlackBoard)
+		if !ok {
+			return 0
+		}
+		v, err := board.GetValueAsInterface(key)
+		if err != nil {
+			return 0
+		}
+		var x float64
+		switch n := v.(type) {
+		case int:
+			x = float64(n)
+		case float32:
+			x = float64(n)
+		case float64:
+			x = n
+		default:
+			return 0
+		}
+		if max != min {
+			x = (x - min) / (max - min)
+		}
+		return curve(math.Max(0, math.Min(1, x)))
+	}
+}
+
+func Constant(score float64) node.ScorerFunc {
+	return func(input interface{}) float64 {
+		return score
+	}
+}
+
+// product of the scores, for considerations which must all be high
+func Product(scorers ...node.IScorer) node.ScorerFunc {
+	return func(input interface{}) float64 {
+		score := 1.0
+		for _, scorer := range scorers {
+			score *= scorer.Score(input)
+		}
+		return score
+	}
+}
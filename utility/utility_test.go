@@ -0,0 +1,59 @@
+/*
+ * Description: Behaviour tree in Go.
+ * Copyright (c) 2014-2015 ShionRyuu <shionryuu@outlook.com>.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package utility
+
+import (
+	"testing"
+
+	bb "github.com/ShionRyuu/gobevtree/blackboard"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUtility(t *testing.T) {
+	Convey("Curves", t, func() {
+		So(Linear(-1, 1)(0.25), ShouldEqual, 0.75)
+		So(Power(2)(0.5), ShouldEqual, 0.25)
+		So(Logistic(10, 0.5)(0.5), ShouldEqual, 0.5)
+		So(Logistic(10, 0.5)(1), ShouldBeGreaterThan, 0.99)
+		So(Inverse(Power(2))(0.5), ShouldEqual, 0.75)
+		So(Step(0.3)(0.2), ShouldEqual, 0)
+		So(Step(0.3)(0.3), ShouldEqual, 1)
+		So(Clamp(Linear(2, 0), 0, 1)(0.8), ShouldEqual, 1)
+	})
+
+	Convey("Scorers read numbers from the blackboard", t, func() {
+		board := bb.NewBlackboard()
+		board.SetValueAsInt(1, 25)
+		board.SetValueAsFloat64(2, 150.0)
+		board.SetValueAsString(3, "full")
+
+		So(Key(1, 0, 100, Linear(1, 0)).Score(board), ShouldEqual, 0.25)
+		So(Key(2, 0, 100, Linear(1, 0)).Score(board), ShouldEqual, 1)
+		So(Key(3, 0, 100, Linear(1, 0)).Score(board), ShouldEqual, 0)
+		So(Key(4, 0, 100, Linear(1, 0)).Score(board), ShouldEqual, 0)
+		So(Key(1, 0, 100, Linear(1, 0)).Score(nil), ShouldEqual, 0)
+
+		So(Product(Constant(0.5), Key(1, 0, 100, Linear(1, 0))).Score(board), ShouldEqual, 0.125)
+	})
+}